@@ -0,0 +1,73 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// defaultAlignmentWindow is the number of lines used to align the fields
+// when no window is configured
+const defaultAlignmentWindow = 10
+
+// alignState keep the fields of the recent lines for tabwriter alignment, it
+// is shared between a logger and its child loggers writing to the same output
+type alignState struct {
+	mu   sync.Mutex
+	rows [][]string
+	out  bytes.Buffer
+	tw   *tabwriter.Writer
+}
+
+// WithAlignedFields pad the structured fields in text format so they line up
+// in columns with the fields of the recent lines
+func (l *Logger) WithAlignedFields() *Logger {
+	return l.update(func(c *Config) {
+		c.AlignedFields = true
+	})
+}
+
+// WithoutAlignedFields turn off the structured fields alignment
+func (l *Logger) WithoutAlignedFields() *Logger {
+	return l.update(func(c *Config) {
+		c.AlignedFields = false
+	})
+}
+
+// WithAlignmentWindow set the number of recent lines used to compute the
+// field column width
+func (l *Logger) WithAlignmentWindow(n int) *Logger {
+	return l.update(func(c *Config) {
+		c.AlignmentWindow = n
+	})
+}
+
+// alignCells returns the cells padded to the column width of the recent
+// lines
+func (l *Logger) alignCells(window int, cells []string) string {
+	if window <= 0 {
+		window = defaultAlignmentWindow
+	}
+	l.align.mu.Lock()
+	defer l.align.mu.Unlock()
+	// Slide the window and add the current line as the last row
+	if len(l.align.rows) >= window {
+		l.align.rows = append(l.align.rows[:0], l.align.rows[len(l.align.rows)-window+1:]...)
+	}
+	l.align.rows = append(l.align.rows, cells)
+	// Run every row through the tabwriter and keep only the last line
+	if l.align.tw == nil {
+		l.align.tw = tabwriter.NewWriter(&l.align.out, 0, 0, 1, ' ', 0)
+	}
+	l.align.out.Reset()
+	for _, row := range l.align.rows {
+		l.align.tw.Write([]byte(strings.Join(row, "\t") + "\n"))
+	}
+	l.align.tw.Flush()
+	lines := strings.Split(strings.TrimSuffix(l.align.out.String(), "\n"), "\n")
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,163 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// asyncWriter write the lines to the output from a goroutine, so the callers
+// only wait for the queue
+type asyncWriter struct {
+	mu     sync.Mutex
+	out    FdWriter
+	lines  chan asyncLine
+	done   chan struct{}
+	closed bool
+	// err is the last write error, returned by the next Flush
+	err error
+}
+
+// asyncLine is a queued line, or a flush request when flushed is set
+type asyncLine struct {
+	level   Level
+	leveled bool
+	p       []byte
+	flushed chan error
+}
+
+// WithAsync write the lines to the current output from a goroutine through a
+// queue of size lines, the callers only wait when the queue is full. The
+// write errors are returned by the next Flush. Fatal and Panic drain the
+// queue before exiting and Close drain it before closing the output.
+func (l *Logger) WithAsync(size int) *Logger {
+	return l.update(func(c *Config) {
+		c.Out = newAsyncWriter(c.Out, size)
+	})
+}
+
+// newAsyncWriter returns the queue in front of out with its goroutine
+func newAsyncWriter(out FdWriter, size int) *asyncWriter {
+	w := &asyncWriter{
+		out:   out,
+		lines: make(chan asyncLine, size),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queue a copy of the line
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	return w.queue(asyncLine{p: p})
+}
+
+// WriteLevel queue a copy of the line, the level is passed to the output
+// when it need it such as TeeWriter
+func (w *asyncWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return w.queue(asyncLine{level: level, leveled: true, p: p})
+}
+
+// queue send the line to the goroutine, it returns os.ErrClosed once closed
+func (w *asyncWriter) queue(line asyncLine) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	// Copy the line as the caller reuse its buffer
+	n := len(line.p)
+	line.p = append([]byte(nil), line.p...)
+	w.lines <- line
+	return n, nil
+}
+
+// run write the queued lines to the output until the queue is closed
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	lw, ok := w.out.(levelWriter)
+	for line := range w.lines {
+		if line.flushed != nil {
+			err := w.err
+			w.err = nil
+			if flusher, ok := w.out.(interface{ Flush() error }); ok && err == nil {
+				err = flusher.Flush()
+			}
+			line.flushed <- err
+			continue
+		}
+		var err error
+		if ok && line.leveled {
+			_, err = lw.WriteLevel(line.level, line.p)
+		} else {
+			_, err = w.out.Write(line.p)
+		}
+		if err != nil {
+			w.err = err
+		}
+	}
+}
+
+// Fd returns the file descriptor of the output
+func (w *asyncWriter) Fd() uintptr {
+	return w.out.Fd()
+}
+
+// Flush wait until the queued lines are written and flush the output if it
+// support flushing, it returns the last write error since the previous Flush
+func (w *asyncWriter) Flush() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	flushed := make(chan error, 1)
+	w.lines <- asyncLine{flushed: flushed}
+	w.mu.Unlock()
+	return <-flushed
+}
+
+// Sync wait until the queued lines are written and commit the output to
+// stable storage if it support syncing
+func (w *asyncWriter) Sync() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if s, ok := w.out.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// RotateNow wait until the queued lines are written and rotate the output if
+// it support manual rotation
+func (w *asyncWriter) RotateNow() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return rotateOutput(w.out)
+}
+
+// Close wait until the queued lines are written, stop the goroutine and close
+// the output if it implements io.Closer
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return os.ErrClosed
+	}
+	w.closed = true
+	close(w.lines)
+	w.mu.Unlock()
+	<-w.done
+	err := w.err
+	if closer, ok := w.out.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"reflect"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// WithHashChain add the prev=<hash> field to every log line, the hex encoded
+// SHA-256 of the previous line as written including its newline, so a removed
+// or altered line break the chain. The first line has the hash of zeros. A
+// chain is kept for every output, shared with the child loggers writing to
+// it, and start again when the process restart.
+func (l *Logger) WithHashChain() *Logger {
+	return l.update(func(c *Config) {
+		c.HashChain = true
+	})
+}
+
+// WithoutHashChain turn off the hash chain output on the log
+func (l *Logger) WithoutHashChain() *Logger {
+	return l.update(func(c *Config) {
+		c.HashChain = false
+	})
+}
+
+// WithAudit turn on the sequence ID and the hash chain for an append-only
+// audit log, the missing, duplicated or altered lines can then be detected.
+// Both the counter and the chain are kept in memory by the logger, they
+// restart from 1 and from the hash of zeros when the process restart.
+func (l *Logger) WithAudit() *Logger {
+	return l.WithSequenceID().WithHashChain()
+}
+
+// chainOf returns the hash chain of the output, caller must hold the write
+// lock. The outputs which cannot be a map key share a single chain.
+func (s *sharedState) chainOf(out io.Writer) *[sha256.Size]byte {
+	if out != nil && !reflect.TypeOf(out).Comparable() {
+		out = nil
+	}
+	chain := s.chains[out]
+	if chain == nil {
+		if s.chains == nil {
+			s.chains = make(map[io.Writer]*[sha256.Size]byte)
+		}
+		chain = new([sha256.Size]byte)
+		s.chains[out] = chain
+	}
+	return chain
+}
+
+// appendHash write the hex encoded hash to the buffer
+func appendHash(buf *colorful.ColorBuffer, hash *[sha256.Size]byte) {
+	var repr [2 * sha256.Size]byte
+	hex.Encode(repr[:], hash[:])
+	buf.Append(repr[:])
+}
@@ -0,0 +1,140 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Benchmark file
+
+package log
+
+import (
+	"io"
+	"sync"
+	"testing"
+)
+
+func benchmarkOutput(b *testing.B, timestamp, caller bool) {
+	l := NewDiscardLogger()
+	if timestamp {
+		l.WithTimestamp()
+	}
+	prefix := l.prefix(LevelInfo)
+	prefix.File = caller
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Output(1, prefix, "hello world")
+	}
+}
+
+func BenchmarkOutputPlain(b *testing.B) {
+	benchmarkOutput(b, false, false)
+}
+
+func BenchmarkOutputTimestamp(b *testing.B) {
+	benchmarkOutput(b, true, false)
+}
+
+func BenchmarkOutputCaller(b *testing.B) {
+	benchmarkOutput(b, false, true)
+}
+
+func BenchmarkOutputTimestampCaller(b *testing.B) {
+	benchmarkOutput(b, true, true)
+}
+
+func BenchmarkInfoText(b *testing.B) {
+	l := NewDiscardLogger().WithTimestamp()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello world")
+	}
+}
+
+func BenchmarkInfoJSON(b *testing.B) {
+	l := NewDiscardLogger().WithTimestamp().WithFormat(FormatJSON)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello world")
+	}
+}
+
+func BenchmarkInfoAsync(b *testing.B) {
+	l := NewDiscardLogger().WithTimestamp()
+	l.SetOutput(NewFdWriterBridge(io.Discard, ^uintptr(0))).WithAsync(1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello world")
+	}
+	// Count the queued lines as well and stop the writer goroutine
+	l.Close()
+}
+
+func BenchmarkInfoWithFields(b *testing.B) {
+	l := NewDiscardLogger().WithTimestamp().withFields(
+		Field{Key: "user", Value: "al"},
+		Field{Key: "id", Value: 7},
+		Field{Key: "elapsed", Value: 1.5},
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello world")
+	}
+}
+
+func BenchmarkConcurrentInfo(b *testing.B) {
+	l := NewDiscardLogger().WithTimestamp()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("hello world")
+		}
+	})
+}
+
+// benchmarkContended log from 100 goroutines at once, the lines are formatted
+// outside the write lock unless the logger numbers them
+func benchmarkContended(b *testing.B, l *Logger) {
+	const goroutines = 100
+	l = l.WithTimestamp().withFields(
+		Field{Key: "user", Value: "al"},
+		Field{Key: "id", Value: 7},
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		n := b.N / goroutines
+		if g < b.N%goroutines {
+			n++
+		}
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				l.Info("hello world")
+			}
+		}(n)
+	}
+	wg.Wait()
+}
+
+func BenchmarkContendedInfo(b *testing.B) {
+	benchmarkContended(b, NewDiscardLogger())
+}
+
+func BenchmarkContendedInfoSequence(b *testing.B) {
+	benchmarkContended(b, NewDiscardLogger().WithSequenceID())
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	l := NewDiscardLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Debug("hello world")
+	}
+}
@@ -0,0 +1,23 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "io"
+
+// fdWriterBridge add a fixed file descriptor to an io.Writer
+type fdWriterBridge struct {
+	io.Writer
+	fd uintptr
+}
+
+// NewFdWriterBridge returns FdWriter writing to w and reporting fd as its
+// file descriptor, e.g. to log into a bytes.Buffer in tests with fd 0
+func NewFdWriterBridge(w io.Writer, fd uintptr) FdWriter {
+	return &fdWriterBridge{Writer: w, fd: fd}
+}
+
+// Fd returns the file descriptor given to NewFdWriterBridge
+func (w *fdWriterBridge) Fd() uintptr {
+	return w.fd
+}
@@ -3,6 +3,11 @@
 
 package buffer
 
+import (
+	"strconv"
+	"time"
+)
+
 // Buffer type wrap up byte slice built-in type
 type Buffer []byte
 
@@ -16,6 +21,11 @@ func (b *Buffer) Append(data []byte) {
 	*b = append(*b, data...)
 }
 
+// AppendString to buffer without converting it to a byte slice first
+func (b *Buffer) AppendString(data string) {
+	*b = append(*b, data...)
+}
+
 // AppendByte to buffer
 func (b *Buffer) AppendByte(data byte) {
 	*b = append(*b, data)
@@ -23,9 +33,26 @@ func (b *Buffer) AppendByte(data byte) {
 
 // AppendInt to buffer
 func (b *Buffer) AppendInt(val int, width int) {
-	var repr [8]byte
+	var repr [20]byte
+	reprCount := len(repr) - 1
+	// The padding is limited to the 20 digits of the buffer
+	for val >= 10 || width > 1 && reprCount > 0 {
+		reminder := val / 10
+		repr[reprCount] = byte('0' + val - reminder*10)
+		val = reminder
+		reprCount--
+		width--
+	}
+	repr[reprCount] = byte('0' + val)
+	b.Append(repr[reprCount:])
+}
+
+// AppendUint to buffer, zero padded to width digits like AppendInt
+func (b *Buffer) AppendUint(val uint64, width int) {
+	var repr [20]byte
 	reprCount := len(repr) - 1
-	for val >= 10 || width > 1 {
+	// The padding is limited to the 20 digits of the largest value
+	for val >= 10 || width > 1 && reprCount > 0 {
 		reminder := val / 10
 		repr[reprCount] = byte('0' + val - reminder*10)
 		val = reminder
@@ -36,6 +63,119 @@ func (b *Buffer) AppendInt(val int, width int) {
 	b.Append(repr[reprCount:])
 }
 
+// AppendFloat to buffer in 'g' format with prec significant digits, the
+// shortest representation for prec -1. The optional bitSize is 32 for
+// float32 and 64 for float64 value, the default
+func (b *Buffer) AppendFloat(val float64, prec int, bitSize ...int) {
+	bits := 64
+	if len(bitSize) > 0 {
+		bits = bitSize[0]
+	}
+	*b = strconv.AppendFloat(*b, val, 'g', prec, bits)
+}
+
+// AppendDuration to buffer in the same format as time.Duration.String such
+// as 1.234ms, 2.1s or 1h2m0.5s
+func (b *Buffer) AppendDuration(d time.Duration) {
+	// Largest duration is 2540400h10m10.000000000s
+	var repr [32]byte
+	w := len(repr)
+	u := uint64(d)
+	neg := d < 0
+	if neg {
+		u = -u
+	}
+	if u < uint64(time.Second) {
+		// Use smaller unit for sub-second duration and no fraction
+		var prec int
+		w--
+		repr[w] = 's'
+		w--
+		switch {
+		case u == 0:
+			b.AppendString("0s")
+			return
+		case u < uint64(time.Microsecond):
+			prec = 0
+			repr[w] = 'n'
+		case u < uint64(time.Millisecond):
+			prec = 3
+			// U+00B5 'µ' micro sign is 0xC2 0xB5
+			w--
+			copy(repr[w:], "\u00b5")
+		default:
+			prec = 6
+			repr[w] = 'm'
+		}
+		w, u = fmtFrac(repr[:w], u, prec)
+		w = fmtInt(repr[:w], u)
+	} else {
+		w--
+		repr[w] = 's'
+		w, u = fmtFrac(repr[:w], u, 9)
+		// u is now integer seconds
+		w = fmtInt(repr[:w], u%60)
+		u /= 60
+		// u is now integer minutes
+		if u > 0 {
+			w--
+			repr[w] = 'm'
+			w = fmtInt(repr[:w], u%60)
+			u /= 60
+			// u is now integer hours
+			if u > 0 {
+				w--
+				repr[w] = 'h'
+				w = fmtInt(repr[:w], u)
+			}
+		}
+	}
+	if neg {
+		w--
+		repr[w] = '-'
+	}
+	b.Append(repr[w:])
+}
+
+// fmtFrac format the fraction of v/10**prec (e.g. ".12345") into the tail of
+// repr omitting trailing zeros, it returns the index where the output begins
+// and v/10**prec
+func fmtFrac(repr []byte, v uint64, prec int) (int, uint64) {
+	w := len(repr)
+	printed := false
+	for i := 0; i < prec; i++ {
+		digit := v % 10
+		printed = printed || digit != 0
+		if printed {
+			w--
+			repr[w] = byte(digit) + '0'
+		}
+		v /= 10
+	}
+	if printed {
+		w--
+		repr[w] = '.'
+	}
+	return w, v
+}
+
+// fmtInt format v into the tail of repr, it returns the index where the
+// output begins
+func fmtInt(repr []byte, v uint64) int {
+	w := len(repr)
+	if v == 0 {
+		w--
+		repr[w] = '0'
+		return w
+	}
+	for v > 0 {
+		w--
+		repr[w] = byte(v%10) + '0'
+		v /= 10
+	}
+	return w
+}
+
 // Bytes return underlying slice data
 func (b Buffer) Bytes() []byte {
 	return []byte(b)
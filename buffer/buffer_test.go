@@ -6,7 +6,9 @@
 package buffer
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -24,6 +26,23 @@ func TestBufferAllocation(t *testing.T) {
 			})
 		})
 
+		Convey("When appended with string", func() {
+			buf.AppendString("Hello")
+
+			Convey("It should have same content as the original string", func() {
+				So(string(buf.Bytes()), ShouldEqual, "Hello")
+			})
+
+			Convey("It should not allocate beyond the buffer growth", func() {
+				buf.Reset()
+				allocs := testing.AllocsPerRun(100, func() {
+					buf.Reset()
+					buf.AppendString("Hello")
+				})
+				So(allocs, ShouldEqual, 0)
+			})
+		})
+
 		Convey("When appended with single byte", func() {
 			data := byte('H')
 			buf.AppendByte(data)
@@ -46,6 +65,24 @@ func TestBufferAllocation(t *testing.T) {
 				So(buf.Bytes(), ShouldResemble, repr)
 			})
 		})
+
+		Convey("When appended with integer wider than its buffer", func() {
+			buf.AppendInt(7, 30)
+
+			Convey("Should pad it to 20 digits without panic", func() {
+				So(string(buf.Bytes()), ShouldEqual, "00000000000000000007")
+			})
+		})
+
+		Convey("When appended with unsigned integer", func() {
+			buf.AppendUint(42, 4)
+			buf.AppendByte(' ')
+			buf.AppendUint(math.MaxUint64, 0)
+
+			Convey("Should have same content with the zero padded representation", func() {
+				So(string(buf.Bytes()), ShouldEqual, "0042 18446744073709551615")
+			})
+		})
 	})
 }
 
@@ -74,3 +111,71 @@ func TestBufferReset(t *testing.T) {
 		})
 	})
 }
+
+func TestBufferAppendFloat(t *testing.T) {
+	Convey("Given empty buffer", t, func() {
+		var buf Buffer
+
+		Convey("When appended with float in shortest format", func() {
+			buf.AppendFloat(1.5, -1, 64)
+
+			Convey("It should have the float representation", func() {
+				So(string(buf.Bytes()), ShouldEqual, "1.5")
+			})
+		})
+
+		Convey("When appended with float and precision only", func() {
+			buf.AppendFloat(3.14159, 3)
+
+			Convey("It should have the float64 representation with the precision", func() {
+				So(string(buf.Bytes()), ShouldEqual, "3.14")
+			})
+		})
+
+		Convey("When appended with float32 value", func() {
+			buf.AppendFloat(float64(float32(0.1)), -1, 32)
+
+			Convey("It should use the float32 precision", func() {
+				So(string(buf.Bytes()), ShouldEqual, "0.1")
+			})
+		})
+
+		Convey("It should append without allocation", func() {
+			buf.AppendFloat(0, -1, 64)
+			allocs := testing.AllocsPerRun(100, func() {
+				buf.Reset()
+				buf.AppendFloat(12.345, -1, 64)
+				buf.AppendFloat(12.345, -1)
+			})
+			So(allocs, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestBufferAppendDuration(t *testing.T) {
+	Convey("Given durations of every magnitude", t, func() {
+		durations := []time.Duration{
+			0, 30 * time.Nanosecond, 30 * time.Microsecond, 1234 * time.Microsecond,
+			2100 * time.Millisecond, -1500 * time.Millisecond, 3723500 * time.Millisecond,
+			time.Duration(1<<63 - 1), time.Duration(-1 << 63),
+		}
+
+		Convey("It should have the same format as Duration.String", func() {
+			for _, d := range durations {
+				var buf Buffer
+				buf.AppendDuration(d)
+				So(string(buf.Bytes()), ShouldEqual, d.String())
+			}
+		})
+
+		Convey("It should append without allocation", func() {
+			var buf Buffer
+			buf.AppendDuration(time.Hour)
+			allocs := testing.AllocsPerRun(100, func() {
+				buf.Reset()
+				buf.AppendDuration(1234 * time.Microsecond)
+			})
+			So(allocs, ShouldEqual, 0)
+		})
+	})
+}
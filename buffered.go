@@ -0,0 +1,94 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "sync"
+
+// Entry is a child logger with the same configuration as its parent
+type Entry struct {
+	*Logger
+}
+
+// memoryWriter keep every written line in memory
+type memoryWriter struct {
+	mu    sync.Mutex
+	fd    uintptr
+	lines []memoryLine
+}
+
+// memoryLine is a stored line with its level when the logger passed it
+type memoryLine struct {
+	level   Level
+	leveled bool
+	p       []byte
+}
+
+// Write store a copy of the line since the logger reuse its buffer
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	return w.store(memoryLine{p: p})
+}
+
+// WriteLevel store a copy of the line with its level, so flush can pass it to
+// the writer of the level
+func (w *memoryWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return w.store(memoryLine{level: level, leveled: true, p: p})
+}
+
+// store append a copy of the line
+func (w *memoryWriter) store(line memoryLine) (int, error) {
+	n := len(line.p)
+	line.p = append([]byte(nil), line.p...)
+	w.mu.Lock()
+	w.lines = append(w.lines, line)
+	w.mu.Unlock()
+	return n, nil
+}
+
+// Fd returns the file descriptor of the original output
+func (w *memoryWriter) Fd() uintptr {
+	return w.fd
+}
+
+// drain returns and forget all stored lines
+func (w *memoryWriter) drain() []memoryLine {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := w.lines
+	w.lines = nil
+	return lines
+}
+
+// Buffered returns an entry which keep its log lines in memory, formatted and
+// timestamped at log time. Calling flush write the lines in order to the
+// logger output, or the writer of their level, without being interleaved by
+// other writes, not calling it discard them.
+func (l *Logger) Buffered() (*Entry, func()) {
+	entry := &Entry{l.child()}
+	mem := &memoryWriter{fd: l.config.Load().Out.Fd()}
+	entry.update(func(c *Config) {
+		// Keep every level in memory, flush restore the level writers
+		c.Out = mem
+		c.writers = [numLevels]FdWriter{}
+	})
+	flush := func() {
+		lines := mem.drain()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		c := l.config.Load()
+		for _, line := range lines {
+			var err error
+			if !line.leveled {
+				_, err = c.Out.Write(line.p)
+			} else if lw, ok := c.levelOutput(line.level).(levelWriter); ok {
+				_, err = lw.WriteLevel(line.level, line.p)
+			} else {
+				_, err = c.levelOutput(line.level).Write(line.p)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	return entry, flush
+}
@@ -0,0 +1,81 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CallerFormat define how the caller info is written in text format
+type CallerFormat int
+
+// Available caller formats, the zero value is CallerFunc
+const (
+	// CallerFunc write the function, file name and line, such as
+	// main.run:main.go:42
+	CallerFunc CallerFormat = iota
+	// CallerFile write the file name and line, such as main.go:42
+	CallerFile
+	// CallerPath write the file path relative to the working directory and
+	// the line, such as cmd/app/main.go:42, which the editors and terminals
+	// can open on click when run from the module root
+	CallerPath
+)
+
+// workDir is the directory CallerPath is relative to
+var workDir, _ = os.Getwd()
+
+// outputWidth returns the number of columns of the terminal with the file
+// descriptor fd, or zero when it is not a terminal
+var outputWidth = terminalWidth
+
+// WithCallerFormat set how the caller info is written in text format. With
+// CallerPath the file is the relative path in every format, including the
+// file passed to the caller filter.
+func (l *Logger) WithCallerFormat(format CallerFormat) *Logger {
+	return l.update(func(c *Config) {
+		c.CallerFormat = format
+	})
+}
+
+// callerFile returns the file of the caller as written in the log line, the
+// file name or with CallerPath the path relative to the working directory. A
+// file outside of it keep its absolute path, and the path of a binary built
+// with -trimpath is already relative to the module.
+func (c *Config) callerFile(path string) string {
+	if c.CallerFormat != CallerPath {
+		return filepath.Base(path)
+	}
+	if workDir == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// WithMinCallerWidth hide the caller info when the output is a terminal
+// narrower than width columns, where it would wrap most lines. The width is
+// measured when the output is set, zero turn it off. WithCaller still print
+// the caller info whatever the width.
+func (l *Logger) WithMinCallerWidth(width int) *Logger {
+	return l.update(func(c *Config) {
+		c.MinCallerWidth = width
+		c.detectWidth()
+	})
+}
+
+// detectWidth check whether the output is a terminal too narrow for the
+// caller info
+func (c *Config) detectWidth() {
+	c.narrow = false
+	if c.MinCallerWidth > 0 && c.Out != nil {
+		width := outputWidth(c.Out.Fd())
+		c.narrow = width > 0 && width < c.MinCallerWidth
+	}
+}
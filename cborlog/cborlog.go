@@ -0,0 +1,181 @@
+// CBOR encoding for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+// Package cborlog encode the log lines as CBOR (Concise Binary Object
+// Representation) maps for compact machine ingestion. It is a separate module
+// so the core library stays dependency free.
+package cborlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	log "github.com/csturiale/go-log"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Integer keys of the standard members of a log entry. There is no IANA
+// registry of log field numbers so the numbering is owned by this package and
+// never changes, the other fields keep their string key.
+const (
+	KeyTime       = 1
+	KeyLevel      = 2
+	KeyMessage    = 3
+	KeyPrefix     = 4
+	KeyCaller     = 5
+	KeyFunc       = 6
+	KeyHost       = 7
+	KeyPID        = 8
+	KeyGoroutine  = 9
+	KeyStack      = 10
+	KeyError      = 11
+	KeyErrorChain = 12
+)
+
+// keys map the JSON member names written by the logger to their integer key
+var keys = map[string]int{
+	"time":        KeyTime,
+	"level":       KeyLevel,
+	"msg":         KeyMessage,
+	"prefix":      KeyPrefix,
+	"caller":      KeyCaller,
+	"func":        KeyFunc,
+	"host":        KeyHost,
+	"pid":         KeyPID,
+	"gid":         KeyGoroutine,
+	"stack":       KeyStack,
+	"error":       KeyError,
+	"error_chain": KeyErrorChain,
+}
+
+// tagDateTime is the CBOR tag of a RFC 3339 date and time string
+const tagDateTime = 0
+
+// encMode sort the map keys so the same entry is always encoded the same
+var encMode, _ = cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+
+// Writer re-encode the JSON lines of the logger as CBOR maps, each prefixed
+// with its length as 4-byte big-endian integer
+type Writer struct {
+	mu    sync.Mutex
+	out   io.Writer
+	frame []byte
+}
+
+// NewWriter returns a writer of the length prefixed CBOR entries to out, the
+// logger must use the JSON format with the default JSONKeys
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// WithCBORFormat switch l to write length prefixed CBOR entries to its
+// current output. The JSONKeys are reset since the standard members get
+// their integer key, they must not be changed afterward.
+func WithCBORFormat(l *log.Logger) *log.Logger {
+	return l.WithoutColor().WithFormat(log.FormatJSON).WithJSONKeys(log.JSONKeys{}).SetOutput(NewWriter(l.GetOutput()))
+}
+
+// Write encode every JSON object of p as one CBOR entry written to the
+// output at once. On error it returns the number of bytes of p of the
+// entries already written.
+func (w *Writer) Write(p []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	written := 0
+	for {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err == io.EOF {
+			return len(p), nil
+		} else if err != nil {
+			return written, err
+		}
+		b, err := encMode.Marshal(convert(entry))
+		if err != nil {
+			return written, err
+		}
+		if uint64(len(b)) > 0xffffffff {
+			return written, errors.New("cborlog: entry too large")
+		}
+		w.frame = binary.BigEndian.AppendUint32(w.frame[:0], uint32(len(b)))
+		w.frame = append(w.frame, b...)
+		if _, err := w.out.Write(w.frame); err != nil {
+			return written, err
+		}
+		// Count the line terminator with the entry
+		written = int(dec.InputOffset())
+		for written < len(p) && (p[written] == '\n' || p[written] == '\r') {
+			written++
+		}
+	}
+}
+
+// Fd returns invalid file descriptor, the binary output is never a terminal
+func (w *Writer) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// Flush flush the underlying writer if it support flushing
+func (w *Writer) Flush() error {
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close close the underlying writer if it is a closer
+func (w *Writer) Close() error {
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// convert returns the entry with the integer keys of the standard members
+func convert(entry map[string]interface{}) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, len(entry))
+	for k, v := range entry {
+		key, ok := keys[k]
+		if !ok {
+			m[k] = value(v)
+			continue
+		}
+		if key == KeyTime {
+			if s, ok := v.(string); ok {
+				m[key] = cbor.Tag{Number: tagDateTime, Content: s}
+				continue
+			}
+		}
+		m[key] = value(v)
+	}
+	return m
+}
+
+// value returns the decoded JSON value with the numbers as int64 when they
+// are integers, float64 otherwise
+func value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		for i := range val {
+			val[i] = value(val[i])
+		}
+		return val
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = value(val[k])
+		}
+		return val
+	}
+	return v
+}
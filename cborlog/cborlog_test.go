@@ -0,0 +1,116 @@
+// CBOR encoding for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package cborlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	log "github.com/csturiale/go-log"
+	"github.com/fxamacker/cbor/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// out is the output of the singleton logger returned by Init
+var out testWriter
+
+// next read the next length prefixed entry of out
+func next() map[interface{}]interface{} {
+	size := binary.BigEndian.Uint32(out.Next(4))
+	var entry map[interface{}]interface{}
+	So(cbor.Unmarshal(out.Next(int(size)), &entry), ShouldBeNil)
+	return entry
+}
+
+func TestWithCBORFormat(t *testing.T) {
+	Convey("Given logger with CBOR format", t, func() {
+		out.Reset()
+		parent, err := log.Init(log.Config{Out: &out, Prefix: "svc"})
+		So(err, ShouldBeNil)
+		l := WithCBORFormat(parent.Clone().WithoutCaller())
+
+		Convey("It should use an output which is not a terminal", func() {
+			So(l.GetOutput().Fd(), ShouldEqual, ^uintptr(0))
+		})
+
+		Convey("It should write the standard members with integer keys", func() {
+			l.WithFields(log.Fields{"attempt": 3, "ratio": 0.5}).Info("hello")
+			entry := next()
+			So(entry[uint64(KeyLevel)], ShouldEqual, "INFO")
+			So(entry[uint64(KeyMessage)], ShouldEqual, "hello")
+			So(entry[uint64(KeyPrefix)], ShouldEqual, "svc")
+			So(entry["attempt"], ShouldEqual, uint64(3))
+			So(entry["ratio"], ShouldEqual, 0.5)
+		})
+
+		Convey("It should write the error chain as array", func() {
+			l.WithError(fmt.Errorf("query failed: %w", io.EOF)).Warn("failed")
+			entry := next()
+			So(entry[uint64(KeyError)], ShouldEqual, "query failed: EOF")
+			So(entry[uint64(KeyErrorChain)], ShouldResemble, []interface{}{"EOF"})
+		})
+
+		Convey("It should tag the timestamp as date and time", func() {
+			l.WithTimestamp().Info("hello")
+			So(next()[uint64(KeyTime)], ShouldHaveSameTypeAs, time.Time{})
+		})
+
+		Convey("It should frame consecutive entries", func() {
+			l.Info("first")
+			l.Info("second")
+			So(next()[uint64(KeyMessage)], ShouldEqual, "first")
+			So(next()[uint64(KeyMessage)], ShouldEqual, "second")
+			So(out.Len(), ShouldEqual, 0)
+		})
+
+		Convey("It should map the standard members with custom JSON keys set before", func() {
+			l := WithCBORFormat(parent.Clone().WithoutCaller().WithJSONKeys(log.JSONKeys{Message: "message"}))
+			l.Info("hello")
+			So(next()[uint64(KeyMessage)], ShouldEqual, "hello")
+		})
+	})
+
+	Convey("Given writer failing after the first entry", t, func() {
+		fw := &failWriter{}
+		w := NewWriter(fw)
+
+		Convey("It should return the bytes of the written entries", func() {
+			first := `{"level":"INFO","msg":"first"}` + "\n"
+			n, err := w.Write([]byte(first + `{"level":"INFO","msg":"second"}` + "\n"))
+			So(err, ShouldEqual, io.ErrShortWrite)
+			So(n, ShouldEqual, len(first))
+			So(fw.writes, ShouldEqual, 1)
+		})
+	})
+}
+
+// failWriter accept a single write
+type failWriter struct {
+	writes int
+}
+
+// Write fail after the first write
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.writes > 0 {
+		return 0, io.ErrShortWrite
+	}
+	w.writes++
+	return len(p), nil
+}
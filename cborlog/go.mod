@@ -0,0 +1,17 @@
+module github.com/csturiale/go-log/cborlog
+
+go 1.20
+
+require (
+	github.com/csturiale/go-log v0.0.0
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/smartystreets/goconvey v1.8.0
+)
+
+require (
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v1.13.1 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
+
+replace github.com/csturiale/go-log => ../
@@ -0,0 +1,37 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"errors"
+	"io"
+	"runtime"
+)
+
+// ErrClosed is returned when writing to a closed logger
+var ErrClosed = errors.New("log: logger is closed")
+
+// Close flush the pending lines and close the output if it implements
+// io.Closer. Further writes to the logger and its child loggers, such as
+// WithField, return ErrClosed. The clones have their own state, they only fail
+// to write when the shared output is closed. Closing the singleton returned by
+// Init affects every user of it and Init keeps returning the closed instance.
+func (l *Logger) Close() error {
+	// Check and set under the same lock so only one caller closes
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+	l.flushRepeat()
+	if l.config.Load().LifecycleEvents {
+		runtime.SetFinalizer(l, nil)
+		l.closedEvent()
+	}
+	if closer, ok := l.config.Load().Out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// ColorScope define which parts of a text line are colored when Color is on
+type ColorScope int
+
+// Available color scopes, the zero value is ColorAll
+const (
+	// ColorAll color the level tag, the timestamp and the caller
+	ColorAll ColorScope = iota
+	// ColorLevelOnly color only the level tag such as [INFO]
+	ColorLevelOnly
+	// ColorNone write no color escape at all
+	ColorNone
+)
+
+// WithColorScope set which parts of the line are colored, it has no effect
+// when the color is turned off
+func (l *Logger) WithColorScope(scope ColorScope) *Logger {
+	return l.update(func(c *Config) {
+		c.ColorScope = scope
+	})
+}
+
+// WithColorWholeLine color the whole line with the color of its level
+// instead of the level tag, timestamp and caller each in their own color
+func (l *Logger) WithColorWholeLine() *Logger {
+	return l.update(func(c *Config) {
+		c.ColorWholeLine = true
+	})
+}
+
+// WithoutColorWholeLine go back to coloring the parts of the line according
+// to the color scope
+func (l *Logger) WithoutColorWholeLine() *Logger {
+	return l.update(func(c *Config) {
+		c.ColorWholeLine = false
+	})
+}
+
+// WithColorMessage color the message text with the color of its level, the
+// level tag, timestamp and caller keep their own color
+func (l *Logger) WithColorMessage() *Logger {
+	return l.update(func(c *Config) {
+		c.ColorMessage = true
+	})
+}
+
+// WithoutColorMessage write the message text without color
+func (l *Logger) WithoutColorMessage() *Logger {
+	return l.update(func(c *Config) {
+		c.ColorMessage = false
+	})
+}
+
+// SetLevelColor change the color of the level tag for this logger only, the
+// plain tag is kept
+func (l *Logger) SetLevelColor(level Level, color colorful.Color) error {
+	if !level.valid() {
+		return fmt.Errorf("log: unknown level %d", level)
+	}
+	l.update(func(c *Config) {
+		prefix := &c.prefixes[level.index()]
+		prefix.Color = colorful.Paint(prefix.Plain, color)
+	})
+	return nil
+}
+
+// noColor check the NO_COLOR environment variable (https://no-color.org),
+// any non-empty value turn the color off
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// dumbTerminal check whether TERM is dumb, a terminal without escape
+// sequences support
+func dumbTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// DetectColorSupport check whether the output with the file descriptor fd
+// should be colored, which is when it is the standard output or error of a
+// terminal, NO_COLOR is not set and TERM is not dumb
+func DetectColorSupport(fd uintptr) bool {
+	if noColor() || dumbTerminal() {
+		return false
+	}
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		if f.Fd() == fd {
+			return isTerminal(f)
+		}
+	}
+	return false
+}
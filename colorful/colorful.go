@@ -3,25 +3,50 @@
 
 package colorful
 
-import "github.com/csturiale/go-log/buffer"
+import (
+	"os"
+	"strconv"
+
+	"github.com/csturiale/go-log/buffer"
+)
 
 // ColorBuffer add color option to buffer append
 type ColorBuffer struct {
 	buffer.Buffer
 }
 
+// Color is the ANSI escape sequence turning a color on
+type Color string
+
+// Available colors
+const (
+	ColorRed    Color = "\033[0;31m"
+	ColorGreen  Color = "\033[0;32m"
+	ColorOrange Color = "\033[0;33m"
+	ColorBlue   Color = "\033[0;34m"
+	ColorPurple Color = "\033[0;35m"
+	ColorCyan   Color = "\033[0;36m"
+	ColorGray   Color = "\033[0;37m"
+	ColorYellow Color = "\033[0;93m"
+)
+
 // color pallete map
 var (
 	colorOff    = []byte("\033[0m")
-	colorRed    = []byte("\033[0;31m")
-	colorGreen  = []byte("\033[0;32m")
-	colorOrange = []byte("\033[0;33m")
-	colorBlue   = []byte("\033[0;34m")
-	colorPurple = []byte("\033[0;35m")
-	colorCyan   = []byte("\033[0;36m")
-	colorGray   = []byte("\033[0;37m")
+	colorRed    = []byte(ColorRed)
+	colorGreen  = []byte(ColorGreen)
+	colorOrange = []byte(ColorOrange)
+	colorBlue   = []byte(ColorBlue)
+	colorPurple = []byte(ColorPurple)
+	colorCyan   = []byte(ColorCyan)
+	colorGray   = []byte(ColorGray)
 )
 
+// NewColorBuffer returns an empty color buffer with room for size bytes
+func NewColorBuffer(size int) *ColorBuffer {
+	return &ColorBuffer{Buffer: make(buffer.Buffer, 0, size)}
+}
+
 // Off apply no color to the data
 func (cb *ColorBuffer) Off() {
 	cb.Append(colorOff)
@@ -68,6 +93,11 @@ func mixer(data []byte, color []byte) []byte {
 	return append(append(append(result, color...), data...), colorOff...)
 }
 
+// Paint apply the color c to the data
+func Paint(data []byte, c Color) []byte {
+	return mixer(data, []byte(c))
+}
+
 // Red apply red color to the data
 func Red(data []byte) []byte {
 	return mixer(data, colorRed)
@@ -102,3 +132,48 @@ func Cyan(data []byte) []byte {
 func Gray(data []byte) []byte {
 	return mixer(data, colorGray)
 }
+
+// Color256 apply the color n of the 256-color palette to the data
+func Color256(n uint8, data []byte) []byte {
+	return mixer(data, color256(n))
+}
+
+// TrueColor apply the 24-bit color to the data when the terminal support it
+// according to COLORTERM (truecolor or 24bit), the nearest color of the
+// 256-color palette otherwise
+func TrueColor(r, g, b uint8, data []byte) []byte {
+	if !trueColorSupported() {
+		return mixer(data, color256(nearest256(r, g, b)))
+	}
+	color := append([]byte(nil), "\033[38;2;"...)
+	color = strconv.AppendUint(color, uint64(r), 10)
+	color = append(color, ';')
+	color = strconv.AppendUint(color, uint64(g), 10)
+	color = append(color, ';')
+	color = strconv.AppendUint(color, uint64(b), 10)
+	color = append(color, 'm')
+	return mixer(data, color)
+}
+
+// color256 returns the escape sequence of the color n of the 256-color
+// palette
+func color256(n uint8) []byte {
+	color := append([]byte(nil), "\033[38;5;"...)
+	color = strconv.AppendUint(color, uint64(n), 10)
+	return append(color, 'm')
+}
+
+// trueColorSupported check whether the terminal advertise 24-bit color
+func trueColorSupported() bool {
+	colorTerm := os.Getenv("COLORTERM")
+	return colorTerm == "truecolor" || colorTerm == "24bit"
+}
+
+// nearest256 returns the color of the 6x6x6 cube of the 256-color palette
+// nearest to the 24-bit color
+func nearest256(r, g, b uint8) uint8 {
+	level := func(v uint8) uint8 {
+		return uint8((int(v)*5 + 127) / 255)
+	}
+	return 16 + 36*level(r) + 6*level(g) + level(b)
+}
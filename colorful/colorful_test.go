@@ -115,3 +115,50 @@ func TestColorMixer(t *testing.T) {
 		})
 	})
 }
+
+func TestNewColorBuffer(t *testing.T) {
+	Convey("Given new color buffer", t, func() {
+		cb := NewColorBuffer(64)
+
+		Convey("It should be empty with the requested capacity", func() {
+			So(len(cb.Bytes()), ShouldEqual, 0)
+			So(cap(cb.Bytes()), ShouldEqual, 64)
+		})
+
+		Convey("When appended and reset", func() {
+			cb.Red()
+			cb.Reset()
+
+			Convey("It should be empty again", func() {
+				So(len(cb.Bytes()), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestExtendedColors(t *testing.T) {
+	Convey("Given test data", t, func() {
+		data := []byte("data")
+
+		Convey("It should apply the 256-color palette", func() {
+			So(string(Color256(208, data)), ShouldEqual, "\033[38;5;208mdata\033[0m")
+		})
+
+		Convey("When the terminal support truecolor", func() {
+			t.Setenv("COLORTERM", "truecolor")
+
+			Convey("It should apply the 24-bit color", func() {
+				So(string(TrueColor(255, 128, 0, data)), ShouldEqual, "\033[38;2;255;128;0mdata\033[0m")
+			})
+		})
+
+		Convey("When the terminal does not support truecolor", func() {
+			t.Setenv("COLORTERM", "")
+
+			Convey("It should fall back to the nearest 256-color", func() {
+				So(string(TrueColor(255, 128, 0, data)), ShouldEqual, "\033[38;5;214mdata\033[0m")
+				So(string(TrueColor(0, 0, 0, data)), ShouldEqual, "\033[38;5;16mdata\033[0m")
+			})
+		})
+	})
+}
@@ -0,0 +1,19 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+// If returns a child logger which only write when predicate returns true.
+// The predicate is called on every write without holding any logger lock so
+// it may use the logger itself. Nested If combine the predicates.
+func (l *Logger) If(predicate func() bool) *Logger {
+	return l.child().update(func(c *Config) {
+		if parent := c.predicate; parent != nil {
+			c.predicate = func() bool {
+				return parent() && predicate()
+			}
+		} else {
+			c.predicate = predicate
+		}
+	})
+}
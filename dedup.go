@@ -0,0 +1,90 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"strconv"
+	"time"
+)
+
+// repeatState track the last message for deduplication
+type repeatState struct {
+	key    string
+	prefix Prefix
+	count  int
+	timer  *time.Timer
+}
+
+// WithDedup collapse identical consecutive messages logged within the window
+// into a single "last message repeated N times" line. The summary is written
+// when a different message arrives or when the window elapses.
+func (l *Logger) WithDedup(window time.Duration) *Logger {
+	return l.update(func(c *Config) {
+		c.DedupWindow = window
+	})
+}
+
+// WithoutDedup turn off deduplication and write the pending summary
+func (l *Logger) WithoutDedup() *Logger {
+	l.update(func(c *Config) {
+		c.DedupWindow = 0
+	})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushRepeat()
+	return l
+}
+
+// dedup returns true when the record repeat the last message and must be
+// skipped, caller must hold the write lock
+func (l *Logger) dedup(c *Config, r *record) bool {
+	// Compare the rendered message and fields without the timestamp
+	key := string(r.prefix.Plain) + r.file + ":" + strconv.Itoa(r.line) + "\x00" + string(r.data)
+	for _, field := range r.fields {
+		key += "\x00" + field.Key + "=" + textValue(field.Value)
+	}
+	if key == l.repeat.key {
+		l.repeat.count++
+		return true
+	}
+	l.flushRepeat()
+	l.repeat.key = key
+	// Copy the prefix so the record never escape to the heap
+	l.repeat.prefix = Prefix{
+		Plain: append([]byte(nil), r.prefix.Plain...),
+		Color: append([]byte(nil), r.prefix.Color...),
+		Level: r.prefix.Level,
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(c.DedupWindow, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// Skip if the state has been replaced while waiting for the lock
+		if l.repeat.timer == timer {
+			l.flushRepeat()
+		}
+	})
+	l.repeat.timer = timer
+	return false
+}
+
+// flushRepeat write the summary of the repeated message and reset the state,
+// caller must hold the write lock
+func (l *Logger) flushRepeat() {
+	if l.repeat.timer != nil {
+		l.repeat.timer.Stop()
+	}
+	if l.repeat.count > 0 {
+		buf := getBuffer()
+		c := l.config.Load()
+		l.write(buf, c, &record{
+			now:    c.now(),
+			prefix: l.repeat.prefix,
+			data:   []byte("last message repeated " + strconv.Itoa(l.repeat.count) + " times"),
+			fields: c.fields,
+		})
+		putBuffer(buf)
+	}
+	l.repeat = repeatState{}
+}
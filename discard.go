@@ -0,0 +1,24 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+// discardWriter drop everything written to it
+type discardWriter struct{}
+
+// Write discard p
+func (discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Fd returns invalid file descriptor
+func (discardWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// NewDiscardLogger returns a logger which format every line as usual but drop
+// it instead of writing, useful for benchmarks and tests. Unlike Init it
+// always returns a new logger.
+func NewDiscardLogger() *Logger {
+	return newLogger(Config{Out: discardWriter{}})
+}
@@ -0,0 +1,133 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogEntry is a single log line passed to the entry callback before
+// formatting, not to be confused with the Entry of Buffered
+type LogEntry struct {
+	Level     Level
+	Prefix    string
+	Message   string
+	Timestamp time.Time
+	// File, Line and Func are only set when the caller info is on
+	File string
+	Line int
+	Func string
+	// Fields is never nil, a middleware can add a field to it
+	Fields Fields
+}
+
+// WithEntryCallback call fn with every line written by the logger, in
+// addition to the output, e.g. to accumulate them for an API. Use it on
+// NewDiscardLogger to only capture the lines. The function is called after
+// the write lock is released so it may log itself, a nil fn turn it off.
+func (l *Logger) WithEntryCallback(fn func(entry LogEntry)) *Logger {
+	return l.update(func(c *Config) {
+		c.entryCallback = fn
+	})
+}
+
+// WithEntryBuffering keep the last maxEntries lines written by the logger and
+// its children in memory, in addition to the output, see RecentEntries. A
+// maxEntries below one turn it off.
+func (l *Logger) WithEntryBuffering(maxEntries int) *Logger {
+	return l.update(func(c *Config) {
+		c.entries = nil
+		if maxEntries > 0 {
+			c.entries = &entryRing{entries: make([]LogEntry, maxEntries)}
+		}
+	})
+}
+
+// RecentEntries returns a copy of the last n buffered lines from the oldest
+// to the newest, all of them when n is below one, or nil when the entry
+// buffering is off
+func (l *Logger) RecentEntries(n int) []LogEntry {
+	ring := l.config.Load().entries
+	if ring == nil {
+		return nil
+	}
+	return ring.recent(n)
+}
+
+// newEntry returns the entry of the record with a copy of the fields, the
+// map is allocated even without fields so a middleware can add one. The
+// strings are copied as well and the time of the record is passed apart, so
+// the record never escape to the heap when the callback is off. The time
+// keeps the zone of the clock unless Location is set.
+func newEntry(c *Config, r *record, now time.Time) LogEntry {
+	entry := LogEntry{
+		Level:     r.prefix.Level,
+		Prefix:    strings.Clone(r.name),
+		Message:   string(bytes.TrimSuffix(r.data, []byte("\n"))),
+		Timestamp: now,
+	}
+	if c.Location != nil {
+		entry.Timestamp = entry.Timestamp.In(c.Location)
+	}
+	if r.prefix.File {
+		entry.File = strings.Clone(r.file)
+		entry.Line = r.line
+		entry.Func = strings.Clone(r.fn)
+	}
+	entry.Fields = make(Fields, len(r.fields))
+	for _, field := range r.fields {
+		entry.Fields[field.Key] = field.Value
+	}
+	return entry
+}
+
+// emitEntry pass the record to the entry callback and buffer, now is the time
+// of the record
+func (c *Config) emitEntry(r *record, now time.Time) {
+	entry := newEntry(c, r, now)
+	if c.entries != nil {
+		c.entries.add(entry)
+	}
+	if c.entryCallback != nil {
+		c.entryCallback(entry)
+	}
+}
+
+// entryRing is the fixed size buffer of the recent entries
+type entryRing struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+// add store the entry, replacing the oldest one when the buffer is full
+func (rb *entryRing) add(entry LogEntry) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.entries[rb.next] = entry
+	rb.next++
+	if rb.next == len(rb.entries) {
+		rb.next = 0
+		rb.full = true
+	}
+}
+
+// recent returns a copy of the last n entries from the oldest to the newest
+func (rb *entryRing) recent(n int) []LogEntry {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	entries := make([]LogEntry, 0, len(rb.entries))
+	if rb.full {
+		entries = append(entries, rb.entries[rb.next:]...)
+	}
+	entries = append(entries, rb.entries[:rb.next]...)
+	if n > 0 && n < len(entries) {
+		entries = entries[len(entries)-n:]
+	}
+	return entries
+}
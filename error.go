@@ -0,0 +1,93 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithError returns a child logger with the error message attached as the
+// error field. Errors wrapped with Unwrap, including the errors joined with
+// errors.Join, are attached as the error_chain field, and the stack of the
+// first error providing a StackTrace method (such as the github.com/pkg/errors
+// package) is attached as the stack field.
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l.child()
+	}
+	fields := []Field{{Key: "error", Value: err.Error()}}
+	var chain []string
+	walkErrors(err, func(wrapped error) {
+		chain = append(chain, wrapped.Error())
+	})
+	if len(chain) > 0 {
+		fields = append(fields, Field{Key: "error_chain", Value: chain})
+	}
+	if stack := errorStack(err); stack != "" {
+		fields = append(fields, Field{Key: "stack", Value: stack})
+	}
+	return l.withFields(fields...)
+}
+
+// walkErrors call fn with every error wrapped by err depth first, following
+// both Unwrap() error and Unwrap() []error. The nil errors are skipped, even
+// when typed, since their methods may panic.
+func walkErrors(err error, fn func(err error)) {
+	if isNilError(err) {
+		return
+	}
+	var wrapped []error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		wrapped = []error{u.Unwrap()}
+	case interface{ Unwrap() []error }:
+		wrapped = u.Unwrap()
+	}
+	for _, w := range wrapped {
+		if isNilError(w) {
+			continue
+		}
+		fn(w)
+		walkErrors(w, fn)
+	}
+}
+
+// isNilError check whether err is nil or a typed nil such as a nil pointer
+func isNilError(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// errorStack returns the formatted stack of err or of the first wrapped
+// error implementing the stackTracer interface, without depending on the
+// package defining it
+func errorStack(err error) string {
+	stack := traceOf(err)
+	walkErrors(err, func(wrapped error) {
+		if stack == "" {
+			stack = traceOf(wrapped)
+		}
+	})
+	return stack
+}
+
+// traceOf returns the formatted stack of err if it has a StackTrace method
+func traceOf(err error) string {
+	if isNilError(err) {
+		return ""
+	}
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+	return fmt.Sprintf("%+v", method.Call(nil)[0].Interface())
+}
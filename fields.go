@@ -0,0 +1,90 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Fields is a set of structured fields, passed as the sole argument of Info,
+// Error and the other non formatted methods it is written as fields instead
+// of the Go map syntax
+type Fields map[string]interface{}
+
+// WithFields returns a child logger with the fields attached to every line,
+// the fields are sorted by key. Fields passed inline to a log method take
+// precedence over the fields of the logger with the same key.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	return l.withFields(fields.sorted()...)
+}
+
+// WithField returns a child logger with a single field attached to every line,
+// appended after the existing fields, e.g. a worker number shared by the lines
+// of a goroutine. See WithGoroutineID for the real goroutine ID.
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.withFields(Field{Key: key, Value: value})
+}
+
+// sorted returns the fields ordered by key
+func (f Fields) sorted() []Field {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]Field, len(keys))
+	for i, key := range keys {
+		fields[i] = Field{Key: key, Value: f[key]}
+	}
+	return fields
+}
+
+// outputln write v formatted like fmt.Sprintln, a sole Fields or tagged
+// struct argument is written as structured fields with an empty message
+func (l *Logger) outputln(depth int, prefix Prefix, v []interface{}) error {
+	if len(v) == 1 {
+		if fields, ok := inlineFields(v[0]); ok {
+			return l.output(depth+1, prefix, nil, fields, nil)
+		}
+	}
+	return l.Output(depth+1, prefix, fmt.Sprintln(v...))
+}
+
+// inlineFields returns the fields of a Fields value or of a struct with at
+// least one field tagged with `log:"key"`, a "-" tag skip the field
+func inlineFields(v interface{}) ([]Field, bool) {
+	if fields, ok := v.(Fields); ok {
+		return fields.sorted(), true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, false
+	}
+	rt := rv.Type()
+	var fields []Field
+	tagged := false
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("log")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		tagged = true
+		key := strings.Split(tag, ",")[0]
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = sf.Name
+		}
+		fields = append(fields, Field{Key: key, Value: rv.Field(i).Interface()})
+	}
+	return fields, tagged
+}
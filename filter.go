@@ -0,0 +1,52 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "strings"
+
+// AddFilter drop the lines for which fn returns false, e.g. to quiet a known
+// noisy message of a third-party library. The filters get the message after
+// the redaction and a line is written only when all of them return true.
+func (l *Logger) AddFilter(fn func(level Level, msg string) bool) *Logger {
+	return l.update(func(c *Config) {
+		// Force a new backing array so the clones never share it
+		c.filters = append(c.filters[:len(c.filters):len(c.filters)], fn)
+	})
+}
+
+// allow returns true when every filter accept the message
+func (c *Config) allow(level Level, data []byte) bool {
+	msg := string(data)
+	for _, fn := range c.filters {
+		if !fn(level, msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithCallerFilter drop the lines for which fn returns false given the base
+// name of the caller file and the fully qualified caller function. It is only
+// called when the caller info is enabled for the level, the other lines are
+// always written. A nil fn remove the filter.
+func (l *Logger) WithCallerFilter(fn func(file, fn string) bool) *Logger {
+	return l.update(func(c *Config) {
+		c.callerFilter = fn
+	})
+}
+
+// SuppressPackages returns a caller filter dropping the lines logged from the
+// packages matching a package path prefix, with the same matching as
+// SetLevelForPackage
+func SuppressPackages(pkgPrefixes ...string) func(file, fn string) bool {
+	return func(file, fn string) bool {
+		pkg := packageName(fn)
+		for _, prefix := range pkgPrefixes {
+			if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+				return false
+			}
+		}
+		return true
+	}
+}
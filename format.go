@@ -0,0 +1,659 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// Format define how a log line is encoded
+type Format int
+
+// Available log line formats, the zero value is FormatText
+const (
+	FormatText Format = iota
+	FormatJSON
+	FormatGELF
+)
+
+// JSONKeys rename the standard members of the JSON lines, such as @timestamp
+// for the Elastic Common Schema, an empty key keeps the default name
+type JSONKeys struct {
+	Time    string // default to time
+	Level   string // default to level
+	Message string // default to msg
+	Caller  string // default to caller
+	Func    string // default to func
+}
+
+// key returns the custom key or the default name when it is empty
+func key(custom string, name string) string {
+	if custom != "" {
+		return custom
+	}
+	return name
+}
+
+// DurationFormat define how a time.Duration field is encoded in JSON
+type DurationFormat int
+
+// Available duration formats, the zero value is DurationString
+const (
+	// DurationString write the duration as string, such as "1.5s"
+	DurationString DurationFormat = iota
+	// DurationNanos write the duration as integer nanoseconds
+	DurationNanos
+)
+
+// BytesEncoding define how a []byte field is written
+type BytesEncoding int
+
+// Available bytes encodings, the zero value is BytesHex
+const (
+	// BytesHex write the bytes as lower case hexadecimal, such as 0badcafe
+	BytesHex BytesEncoding = iota
+	// BytesBase64 write the bytes in standard base64 with padding
+	BytesBase64
+	// BytesString write the bytes as string, for the mostly textual data
+	BytesString
+)
+
+// Field is a structured key value pair attached to every line of a logger
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// record hold the values of a single log line before formatting
+type record struct {
+	now    time.Time
+	prefix Prefix
+	name   string
+	file   string
+	line   int
+	fn     string
+	data   []byte
+	stack  []stackFrame
+	// fields are the fields of the logger merged with the fields of the call
+	fields []Field
+	// aligned is the aligned fields, computed once as the line is written
+	// again to the extra writer
+	aligned string
+	seq     uint64
+	prev    [sha256.Size]byte
+}
+
+// WithFormat set the encoding of the log line
+func (l *Logger) WithFormat(format Format) *Logger {
+	return l.update(func(c *Config) {
+		c.Format = format
+	})
+}
+
+// WithJSONKeys set the names of the standard members of the JSON lines
+func (l *Logger) WithJSONKeys(keys JSONKeys) *Logger {
+	return l.update(func(c *Config) {
+		c.JSONKeys = keys
+	})
+}
+
+// WithDurationFormat set the encoding of the time.Duration fields in JSON,
+// the text format always write them as string
+func (l *Logger) WithDurationFormat(format DurationFormat) *Logger {
+	return l.update(func(c *Config) {
+		c.DurationFormat = format
+	})
+}
+
+// RoundDuration round d to a readable precision such as 12ms, e.g. for the
+// duration of the requests logged by the httplog and grpclog middlewares
+func RoundDuration(d time.Duration) time.Duration {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// WithBytesEncoding set the encoding of the []byte fields, the encoded value is
+// truncated like the message when MaxMessageLen is set
+func (l *Logger) WithBytesEncoding(encoding BytesEncoding) *Logger {
+	return l.update(func(c *Config) {
+		c.BytesEncoding = encoding
+	})
+}
+
+// fieldValue returns the []byte field value encoded in the configured
+// encoding and the value masked by the redactors, the other values are
+// returned as is
+func (c *Config) fieldValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		v = c.bytesValue(b)
+	}
+	if len(c.redactors) > 0 {
+		v = c.redactValue(v)
+	}
+	return v
+}
+
+// bytesValue returns the []byte field value encoded in the configured
+// encoding
+func (c *Config) bytesValue(b []byte) string {
+	var s string
+	switch c.BytesEncoding {
+	case BytesBase64:
+		s = base64.StdEncoding.EncodeToString(b)
+	case BytesString:
+		s = string(b)
+	default:
+		s = hex.EncodeToString(b)
+	}
+	if c.MaxMessageLen > 0 {
+		s = string(truncate([]byte(s), c.MaxMessageLen))
+	}
+	return s
+}
+
+// withFields returns a child logger with additional fields, a field replace
+// the value of an existing field with the same key
+func (l *Logger) withFields(fields ...Field) *Logger {
+	return l.child().update(func(c *Config) {
+		c.fields = mergeFields(c.fields, fields)
+	})
+}
+
+// mergeFields returns a copy of base with the fields appended, a field
+// replace the value of a base field with the same key
+func mergeFields(base, fields []Field) []Field {
+	// Copy so the base fields are never shared
+	merged := make([]Field, len(base), len(base)+len(fields))
+	copy(merged, base)
+next:
+	for _, field := range fields {
+		for i := range merged {
+			if merged[i].Key == field.Key {
+				merged[i].Value = field.Value
+				continue next
+			}
+		}
+		merged = append(merged, field)
+	}
+	return merged
+}
+
+// formatText write the record as plain or colored text, caller must hold the
+// write lock unless the config is stateless
+func (l *Logger) formatText(buf *colorful.ColorBuffer, c *Config, r *record) {
+	prefix, data, now := r.prefix, r.data, r.now
+	start := len(buf.Buffer)
+	// Resolve which regions of the line are colored
+	color := c.Color && c.ColorScope != ColorNone
+	whole := color && c.ColorWholeLine
+	decorate := color && c.ColorScope == ColorAll && !whole
+	tint := color && c.ColorMessage && !whole
+	// Write prefix to the buffer
+	if color {
+		buf.Off()
+	}
+	if whole {
+		// The whole line take the level color, reset once at the end
+		buf.Append(levelColor(prefix))
+		defer endColor(buf)
+	}
+	levelFormat := c.levelFormat()
+	if levelFormat == LevelFormatBracketed {
+		buf.Buffer = append(buf.Buffer, '[')
+		buf.AppendString(r.name)
+		buf.Buffer = append(buf.Buffer, ']')
+	} else if r.name != "" {
+		// Separate the prefix from the unbracketed level
+		buf.Buffer = append(buf.Buffer, '[')
+		buf.AppendString(r.name)
+		buf.Buffer = append(buf.Buffer, ']', ' ')
+	}
+	appendLevelTag(buf, c.LevelStyle, levelFormat, prefix, color && !whole)
+	// Fast path for the bare message line, the common case without any
+	// decoration between the prefix and the message
+	if !c.Timestamp && !prefix.File && !c.GoroutineID && !c.SequenceID && !c.HashChain && c.Hostname == "" && c.PID == 0 &&
+		c.MaxMessageLen == 0 && len(r.fields) == 0 && len(r.stack) == 0 {
+		appendGroupIndent(buf, c.groupDepth)
+		if tint {
+			buf.Append(levelColor(prefix))
+		}
+		appendMessage(buf, c.MultiLine, start, data)
+		if len(data) == 0 || data[len(data)-1] != '\n' {
+			buf.AppendByte('\n')
+		}
+		if tint {
+			endColor(buf)
+		}
+		return
+	}
+	// Check if the log require timestamping
+	if c.Timestamp {
+		// Print Timestamp Color if Color enabled
+		if decorate {
+			if c.timestampColor != nil {
+				buf.Append(c.timestampColor)
+			} else {
+				buf.Blue()
+			}
+		}
+		if !c.ElapsedSince.IsZero() {
+			// Print time elapsed since the start
+			appendElapsed(buf, now.Sub(c.ElapsedSince), c.TimePrecision)
+		} else {
+			// Print date and time
+			year, month, day := now.Date()
+			buf.AppendInt(year, 4)
+			buf.AppendByte('/')
+			buf.AppendInt(int(month), 2)
+			buf.AppendByte('/')
+			buf.AppendInt(day, 2)
+			buf.AppendByte(' ')
+			hour, min, sec := now.Clock()
+			buf.AppendInt(hour, 2)
+			buf.AppendByte(':')
+			buf.AppendInt(min, 2)
+			buf.AppendByte(':')
+			buf.AppendInt(sec, 2)
+			appendFraction(buf, now, c.TimePrecision)
+		}
+		buf.AppendByte(' ')
+		// Print reset Color if Color enabled
+		if decorate {
+			buf.Off()
+		}
+	}
+	// Add the goroutine ID if enabled
+	if c.GoroutineID {
+		buf.AppendString("gid=")
+		buf.AppendInt(goroutineID(), 0)
+		buf.AppendByte(' ')
+	}
+	// Add the cached hostname and process ID if enabled
+	if c.Hostname != "" {
+		buf.AppendString("host=")
+		buf.AppendString(c.Hostname)
+		buf.AppendByte(' ')
+	}
+	if c.PID != 0 {
+		buf.AppendString("pid=")
+		buf.AppendInt(c.PID, 0)
+		buf.AppendByte(' ')
+	}
+	if c.SequenceID {
+		buf.AppendString("seq=")
+		buf.AppendUint(r.seq, 0)
+		buf.AppendByte(' ')
+	}
+	if c.HashChain {
+		buf.AppendString("prev=")
+		appendHash(buf, &r.prev)
+		buf.AppendByte(' ')
+	}
+	// Add caller filename and line if enabled
+	if prefix.File {
+		// Print Color start if enabled
+		if decorate {
+			if c.callerColor != nil {
+				buf.Append(c.callerColor)
+			} else {
+				buf.Orange()
+			}
+		}
+		// Print filename and line
+		if c.CallerFormat == CallerFunc {
+			buf.AppendString(r.fn)
+			buf.AppendByte(':')
+		}
+		buf.AppendString(r.file)
+		buf.AppendByte(':')
+		buf.AppendInt(r.line, 0)
+		buf.AppendByte(' ')
+		// Print Color stop
+		if decorate {
+			buf.Off()
+		}
+	}
+	// Print the actual string data from caller
+	if c.MaxMessageLen > 0 {
+		data = truncate(data, c.MaxMessageLen)
+	}
+	if len(r.fields) > 0 {
+		data = bytes.TrimSuffix(data, []byte("\n"))
+	}
+	appendGroupIndent(buf, c.groupDepth)
+	// Tint the message only, the fields keep the default color
+	if tint {
+		buf.Append(levelColor(prefix))
+	}
+	appendMessage(buf, c.MultiLine, start, data)
+	if tint {
+		endColor(buf)
+	}
+	// Pad the message so the fields start at the same column on every line
+	if c.FieldColumn > 0 && len(r.fields) > 0 {
+		for width := visibleWidth(buf.Buffer); width < c.FieldColumn-1; width++ {
+			buf.AppendByte(' ')
+		}
+	}
+	// Print the structured fields after the message, without the separator
+	// when there is no message
+	if c.AlignedFields && len(r.fields) > 0 {
+		if r.aligned == "" {
+			cells := make([]string, len(r.fields))
+			for i, field := range r.fields {
+				cells[i] = field.Key + "=" + textValue(c.fieldValue(field.Value))
+			}
+			r.aligned = l.alignCells(c.AlignmentWindow, cells)
+		}
+		if len(data) > 0 || c.FieldColumn > 0 {
+			buf.AppendByte(' ')
+		}
+		buf.AppendString(r.aligned)
+	} else {
+		for i, field := range r.fields {
+			if i > 0 || len(data) > 0 || c.FieldColumn > 0 {
+				buf.AppendByte(' ')
+			}
+			buf.AppendString(field.Key)
+			buf.AppendByte('=')
+			appendTextValue(buf, c.fieldValue(field.Value))
+		}
+	}
+	if len(data) == 0 || data[len(data)-1] != '\n' || len(r.fields) > 0 {
+		buf.AppendByte('\n')
+	}
+	// Print the captured stack as an indented block
+	for _, frame := range r.stack {
+		buf.AppendByte('\t')
+		buf.AppendString(frame.Func)
+		buf.AppendString("\n\t\t")
+		buf.AppendString(frame.File)
+		buf.AppendByte(':')
+		buf.AppendInt(frame.Line, 0)
+		buf.AppendByte('\n')
+	}
+}
+
+// endColor reset the color of the whole line or of the message before its
+// trailing newline
+func endColor(buf *colorful.ColorBuffer) {
+	if last := len(buf.Buffer) - 1; last >= 0 && buf.Buffer[last] == '\n' {
+		buf.Buffer = buf.Buffer[:last]
+		buf.Off()
+		buf.AppendByte('\n')
+		return
+	}
+	buf.Off()
+}
+
+// formatJSON write the record as a single line JSON object, caller must hold
+// the write lock unless the config is stateless
+func (l *Logger) formatJSON(buf *colorful.ColorBuffer, c *Config, r *record) {
+	data := bytes.TrimSuffix(r.data, []byte("\n"))
+	if c.MaxMessageLen > 0 {
+		data = truncate(data, c.MaxMessageLen)
+	}
+	buf.AppendByte('{')
+	if c.Timestamp {
+		appendJSONKey(buf, key(c.JSONKeys.Time, "time"))
+		buf.AppendByte('"')
+		buf.Buffer = r.now.AppendFormat(buf.Buffer, c.TimePrecision.jsonLayout())
+		buf.AppendByte('"')
+	}
+	appendJSONKey(buf, key(c.JSONKeys.Level, "level"))
+	appendJSONString(buf, r.prefix.Level.String())
+	if r.name != "" {
+		appendJSONKey(buf, "prefix")
+		appendJSONString(buf, r.name)
+	}
+	if c.GoroutineID {
+		appendJSONKey(buf, "gid")
+		buf.AppendInt(goroutineID(), 0)
+	}
+	if c.Hostname != "" {
+		appendJSONKey(buf, "host")
+		appendJSONString(buf, c.Hostname)
+	}
+	if c.PID != 0 {
+		appendJSONKey(buf, "pid")
+		buf.AppendInt(c.PID, 0)
+	}
+	if c.SequenceID {
+		appendJSONKey(buf, "seq")
+		buf.AppendUint(r.seq, 0)
+	}
+	if c.HashChain {
+		appendJSONKey(buf, "prev")
+		buf.AppendByte('"')
+		appendHash(buf, &r.prev)
+		buf.AppendByte('"')
+	}
+	if r.prefix.File {
+		appendJSONKey(buf, key(c.JSONKeys.Caller, "caller"))
+		appendJSONString(buf, r.file+":"+strconv.Itoa(r.line))
+		appendJSONKey(buf, key(c.JSONKeys.Func, "func"))
+		appendJSONString(buf, r.fn)
+	}
+	appendJSONKey(buf, key(c.JSONKeys.Message, "msg"))
+	appendJSONString(buf, string(data))
+	for _, field := range r.fields {
+		appendJSONKey(buf, field.Key)
+		appendJSONValue(buf, c.DurationFormat, c.fieldValue(field.Value))
+	}
+	if len(r.stack) > 0 {
+		appendJSONKey(buf, "stack")
+		appendJSONStack(buf, r.stack)
+	}
+	buf.AppendString("}\n")
+}
+
+// appendJSONKey write the object key with separator from previous member
+func appendJSONKey(buf *colorful.ColorBuffer, key string) {
+	if last := len(buf.Buffer) - 1; last >= 0 && buf.Buffer[last] != '{' {
+		buf.AppendByte(',')
+	}
+	appendJSONString(buf, key)
+	buf.AppendByte(':')
+}
+
+// appendJSONString write quoted and escaped JSON string
+func appendJSONString(buf *colorful.ColorBuffer, s string) {
+	buf.AppendByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.AppendString("\ufffd")
+			} else {
+				buf.AppendString(s[i : i+size])
+			}
+			i += size
+			continue
+		}
+		switch {
+		case c == '"' || c == '\\':
+			buf.AppendByte('\\')
+			buf.AppendByte(c)
+		case c == '\n':
+			buf.AppendString(`\n`)
+		case c == '\r':
+			buf.AppendString(`\r`)
+		case c == '\t':
+			buf.AppendString(`\t`)
+		case c < 0x20:
+			buf.AppendString(`\u00`)
+			buf.AppendByte("0123456789abcdef"[c>>4])
+			buf.AppendByte("0123456789abcdef"[c&0xf])
+		default:
+			buf.AppendByte(c)
+		}
+		i++
+	}
+	buf.AppendByte('"')
+}
+
+// appendJSONValue write any value as JSON, falling back to its string form
+func appendJSONValue(buf *colorful.ColorBuffer, durations DurationFormat, v interface{}) {
+	switch val := v.(type) {
+	case string:
+		appendJSONString(buf, val)
+		return
+	case error:
+		appendJSONString(buf, val.Error())
+		return
+	case time.Duration:
+		if durations == DurationNanos {
+			buf.Buffer = strconv.AppendInt(buf.Buffer, int64(val), 10)
+			return
+		}
+		buf.AppendByte('"')
+		buf.AppendDuration(val)
+		buf.AppendByte('"')
+		return
+	case time.Time:
+		appendJSONString(buf, val.Format(time.RFC3339Nano))
+		return
+	case float64:
+		appendJSONFloat(buf, val, 64)
+		return
+	case float32:
+		appendJSONFloat(buf, float64(val), 32)
+		return
+	}
+	if appendInteger(buf, v) {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		appendJSONString(buf, fmt.Sprint(v))
+		return
+	}
+	buf.Append(b)
+}
+
+// appendJSONFloat write the float as JSON number, NaN and infinity which are
+// not valid numbers are written as string
+func appendJSONFloat(buf *colorful.ColorBuffer, v float64, bitSize int) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		appendJSONString(buf, strconv.FormatFloat(v, 'g', -1, bitSize))
+		return
+	}
+	buf.AppendFloat(v, -1, bitSize)
+}
+
+// appendTextValue write the text form of a field value, the floats and
+// durations are written without going through textValue, the times are
+// written in RFC 3339 format
+func appendTextValue(buf *colorful.ColorBuffer, v interface{}) {
+	switch val := v.(type) {
+	case time.Duration:
+		buf.AppendDuration(val)
+	case float64:
+		buf.AppendFloat(val, -1, 64)
+	case float32:
+		buf.AppendFloat(float64(val), -1, 32)
+	default:
+		if !appendInteger(buf, v) {
+			buf.AppendString(textValue(v))
+		}
+	}
+}
+
+// appendInteger write v in decimal when it is one of the integer types and
+// returns whether it was written
+func appendInteger(buf *colorful.ColorBuffer, v interface{}) bool {
+	switch val := v.(type) {
+	case int:
+		buf.Buffer = strconv.AppendInt(buf.Buffer, int64(val), 10)
+	case int8:
+		buf.Buffer = strconv.AppendInt(buf.Buffer, int64(val), 10)
+	case int16:
+		buf.Buffer = strconv.AppendInt(buf.Buffer, int64(val), 10)
+	case int32:
+		buf.Buffer = strconv.AppendInt(buf.Buffer, int64(val), 10)
+	case int64:
+		buf.Buffer = strconv.AppendInt(buf.Buffer, val, 10)
+	case uint:
+		buf.AppendUint(uint64(val), 0)
+	case uint8:
+		buf.AppendUint(uint64(val), 0)
+	case uint16:
+		buf.AppendUint(uint64(val), 0)
+	case uint32:
+		buf.AppendUint(uint64(val), 0)
+	case uint64:
+		buf.AppendUint(val, 0)
+	case uintptr:
+		buf.AppendUint(uint64(val), 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// visibleWidth returns the number of runes in b not counting the ANSI color
+// escape sequences
+func visibleWidth(b []byte) int {
+	width := 0
+	for i := 0; i < len(b); {
+		if b[i] == '\033' && i+1 < len(b) && b[i+1] == '[' {
+			// Skip until the final byte of the escape sequence
+			i += 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// textValue returns the text form of a field value, quoted when needed
+func textValue(v interface{}) string {
+	s := valueString(v)
+	if _, ok := v.([]string); ok {
+		return s
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\n\t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// valueString returns the string form of a field value without quotes, the
+// []string values are written as a JSON array
+func valueString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case error:
+		return val.Error()
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case []string:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,169 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// gelfLevels map the log levels to syslog severity indexed by level
+var gelfLevels = [numLevels]int{
+	7, // Trace: debug
+	7, // Debug: debug
+	6, // Info: informational
+	4, // Warn: warning
+	3, // Error: error
+	2, // Fatal: critical
+}
+
+// WithGELFFormat encode every line as a GELF 1.1 (Graylog Extended Log
+// Format) JSON object reporting the given host, e.g. to be sent to Graylog
+// with the netlog UDP writer
+func (l *Logger) WithGELFFormat(host string) *Logger {
+	return l.update(func(c *Config) {
+		c.Format = FormatGELF
+		c.GELFHost = host
+	})
+}
+
+// WithGELFTCP terminate every GELF message with a null byte instead of a
+// newline, the framing of the GELF TCP input
+func (l *Logger) WithGELFTCP() *Logger {
+	return l.update(func(c *Config) {
+		c.GELFTCP = true
+	})
+}
+
+// formatGELF write the record as GELF 1.1 JSON object, caller must hold the
+// write lock unless the config is stateless
+func (l *Logger) formatGELF(buf *colorful.ColorBuffer, c *Config, r *record) {
+	data := bytes.TrimSuffix(r.data, []byte("\n"))
+	if c.MaxMessageLen > 0 {
+		data = truncate(data, c.MaxMessageLen)
+	}
+	short := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		short = data[:i]
+	}
+	buf.AppendByte('{')
+	appendJSONKey(buf, "version")
+	appendJSONString(buf, "1.1")
+	appendJSONKey(buf, "host")
+	appendJSONString(buf, c.GELFHost)
+	appendJSONKey(buf, "short_message")
+	appendJSONString(buf, string(short))
+	if len(short) < len(data) {
+		appendJSONKey(buf, "full_message")
+		appendJSONString(buf, string(data))
+	}
+	appendJSONKey(buf, "timestamp")
+	buf.Buffer = strconv.AppendFloat(buf.Buffer, float64(r.now.UnixNano())/1e9, 'f', 3, 64)
+	appendJSONKey(buf, "level")
+	level := 6
+	if r.prefix.Level.valid() {
+		level = gelfLevels[r.prefix.Level.index()]
+	}
+	buf.AppendInt(level, 0)
+	if r.name != "" {
+		appendJSONKey(buf, "_prefix")
+		appendJSONString(buf, r.name)
+	}
+	if c.GoroutineID {
+		appendJSONKey(buf, "_gid")
+		buf.AppendInt(goroutineID(), 0)
+	}
+	if c.PID != 0 {
+		appendJSONKey(buf, "_pid")
+		buf.AppendInt(c.PID, 0)
+	}
+	if c.SequenceID {
+		appendJSONKey(buf, "_seq")
+		buf.AppendUint(r.seq, 0)
+	}
+	if c.HashChain {
+		appendJSONKey(buf, "_prev")
+		buf.AppendByte('"')
+		appendHash(buf, &r.prev)
+		buf.AppendByte('"')
+	}
+	if r.prefix.File {
+		appendJSONKey(buf, "_file")
+		appendJSONString(buf, r.file)
+		appendJSONKey(buf, "_line")
+		buf.AppendInt(r.line, 0)
+		appendJSONKey(buf, "_func")
+		appendJSONString(buf, r.fn)
+	}
+	for _, field := range r.fields {
+		// Skip the fields Graylog would reject the whole message for
+		if !isGELFKey(field.Key) {
+			continue
+		}
+		appendJSONKey(buf, "_"+field.Key)
+		appendGELFValue(buf, c.DurationFormat, c.fieldValue(field.Value))
+	}
+	if len(r.stack) > 0 {
+		appendJSONKey(buf, "_stack")
+		appendJSONString(buf, gelfStack(r.stack))
+	}
+	if c.GELFTCP {
+		buf.AppendString("}\x00")
+	} else {
+		buf.AppendString("}\n")
+	}
+}
+
+// appendGELFValue write the value of an additional field, which Graylog only
+// accept as string or number. The numbers and durations are written like in
+// JSON format, the []string values are joined with commas and the other
+// values are written as their string form.
+func appendGELFValue(buf *colorful.ColorBuffer, durations DurationFormat, v interface{}) {
+	switch val := v.(type) {
+	case float64, float32, time.Duration:
+		appendJSONValue(buf, durations, val)
+	case []string:
+		appendJSONString(buf, strings.Join(val, ","))
+	default:
+		if !appendInteger(buf, v) {
+			appendJSONString(buf, valueString(v))
+		}
+	}
+}
+
+// gelfStack returns the captured stack as a string, one func file:line frame
+// per line
+func gelfStack(frames []stackFrame) string {
+	var sb strings.Builder
+	for i, frame := range frames {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(frame.Func)
+		sb.WriteByte(' ')
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(frame.Line))
+	}
+	return sb.String()
+}
+
+// isGELFKey check whether the field key is valid for an additional field,
+// made of word characters, dots and dashes, and not the reserved id
+func isGELFKey(key string) bool {
+	if key == "" || key == "id" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '.' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
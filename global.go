@@ -0,0 +1,145 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	// stderrOnce create the fallback logger on first use
+	stderrOnce sync.Once
+	// stderrLogger is used by the package level functions when there is no
+	// default logger
+	stderrLogger *Logger
+)
+
+// SetDefault install l as the logger of the package level functions such as
+// Info, and as the instance returned by Init. A nil l restore the fallback
+// logger writing to stderr.
+func SetDefault(l *Logger) {
+	initMu.Lock()
+	defer initMu.Unlock()
+	logger.Store(l)
+}
+
+// Default returns the logger of the package level functions, the instance of
+// Init or SetDefault, or a logger writing to stderr when none is set yet. The
+// fallback logger is never returned by Init.
+func Default() *Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	stderrOnce.Do(func() {
+		stderrLogger = newLogger(Config{
+			Out:   os.Stderr,
+			Color: DetectColorSupport(os.Stderr.Fd()),
+		})
+	})
+	return stderrLogger
+}
+
+// Fatal print fatal message with the default logger and quit the application
+// with status 1
+func Fatal(v ...interface{}) {
+	l := Default()
+	l.outputln(1, l.prefix(LevelFatal), v)
+	l.exit(1)
+}
+
+// Fatalf print formatted fatal message with the default logger and quit the
+// application with status 1
+func Fatalf(format string, v ...interface{}) {
+	l := Default()
+	l.Output(1, l.prefix(LevelFatal), fmt.Sprintf(format, v...))
+	l.exit(1)
+}
+
+// Panic print fatal message with the default logger, flush its output and
+// panic with the message
+func Panic(v ...interface{}) {
+	l := Default()
+	msg := fmt.Sprint(v...)
+	l.Output(1, l.prefix(LevelFatal), msg)
+	l.flush()
+	panic(msg)
+}
+
+// Panicf print formatted fatal message with the default logger, flush its
+// output and panic with the message
+func Panicf(format string, v ...interface{}) {
+	l := Default()
+	msg := fmt.Sprintf(format, v...)
+	l.Output(1, l.prefix(LevelFatal), msg)
+	l.flush()
+	panic(msg)
+}
+
+// Error print error message with the default logger
+func Error(v ...interface{}) {
+	l := Default()
+	l.outputln(1, l.prefix(LevelError), v)
+}
+
+// Errorf print formatted error message with the default logger
+func Errorf(format string, v ...interface{}) {
+	l := Default()
+	l.Output(1, l.prefix(LevelError), fmt.Sprintf(format, v...))
+}
+
+// Warn print warning message with the default logger
+func Warn(v ...interface{}) {
+	l := Default()
+	l.outputln(1, l.prefix(LevelWarn), v)
+}
+
+// Warnf print formatted warning message with the default logger
+func Warnf(format string, v ...interface{}) {
+	l := Default()
+	l.Output(1, l.prefix(LevelWarn), fmt.Sprintf(format, v...))
+}
+
+// Info print informational message with the default logger
+func Info(v ...interface{}) {
+	l := Default()
+	l.outputln(1, l.prefix(LevelInfo), v)
+}
+
+// Infof print formatted informational message with the default logger
+func Infof(format string, v ...interface{}) {
+	l := Default()
+	l.Output(1, l.prefix(LevelInfo), fmt.Sprintf(format, v...))
+}
+
+// Debug print Debug message with the default logger if Debug output enabled
+func Debug(v ...interface{}) {
+	if l := Default(); l.mayEnable(LevelDebug) {
+		l.outputln(1, l.prefix(LevelDebug), v)
+	}
+}
+
+// Debugf print formatted Debug message with the default logger if Debug
+// output enabled
+func Debugf(format string, v ...interface{}) {
+	if l := Default(); l.mayEnable(LevelDebug) {
+		l.Output(1, l.prefix(LevelDebug), fmt.Sprintf(format, v...))
+	}
+}
+
+// Trace print trace message with the default logger if Debug output enabled
+func Trace(v ...interface{}) {
+	if l := Default(); l.mayEnable(LevelTrace) {
+		l.outputln(1, l.prefix(LevelTrace), v)
+	}
+}
+
+// Tracef print formatted trace message with the default logger if Debug
+// output enabled
+func Tracef(format string, v ...interface{}) {
+	if l := Default(); l.mayEnable(LevelTrace) {
+		l.Output(1, l.prefix(LevelTrace), fmt.Sprintf(format, v...))
+	}
+}
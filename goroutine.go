@@ -0,0 +1,45 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutinePrefix is the start of the first line of runtime.Stack output
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID parse the current goroutine ID from the stack header, it
+// returns 0 when the header can not be parsed
+func goroutineID() int {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+	stack = bytes.TrimPrefix(stack, goroutinePrefix)
+	if i := bytes.IndexByte(stack, ' '); i > 0 {
+		stack = stack[:i]
+	}
+	id, err := strconv.Atoi(string(stack))
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// WithGoroutineID add the ID of the logging goroutine as gid=<id> after the
+// timestamp. The ID is parsed from the runtime stack on every write which is
+// slow, use it only in development mode.
+func (l *Logger) WithGoroutineID() *Logger {
+	return l.update(func(c *Config) {
+		c.GoroutineID = true
+	})
+}
+
+// WithoutGoroutineID turn off goroutine ID output on the log
+func (l *Logger) WithoutGoroutineID() *Logger {
+	return l.update(func(c *Config) {
+		c.GoroutineID = false
+	})
+}
@@ -0,0 +1,64 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"sync"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// groupIndent is the indentation of the messages for every nested group
+const groupIndent = "  "
+
+// groupState hold the group of a logger returned by Group
+type groupState struct {
+	name   string
+	parent *Logger
+	once   sync.Once
+}
+
+// Group returns a child logger for the related lines of a multi-step
+// operation. The group name is appended to the prefix such as myapp:db, the
+// messages are indented one more step in text format, and the logger write
+// "begin group: <name>" right away and "end group: <name>" when EndGroup is
+// called, which is required to end the group. The child share the write lock
+// of the logger.
+func (l *Logger) Group(name string) *Logger {
+	child := l.child().update(func(c *Config) {
+		if c.Prefix != "" {
+			c.Prefix += ":"
+		}
+		c.Prefix += name
+		c.groupDepth++
+	})
+	child.group = &groupState{name: name, parent: l}
+	l.Output(1, l.prefix(LevelInfo), "begin group: "+name)
+	return child
+}
+
+// EndGroup write the end line of a logger returned by Group through the
+// parent logger, only the first call has an effect and it does nothing for
+// the other loggers
+func (l *Logger) EndGroup() {
+	g := l.group
+	if g == nil {
+		return
+	}
+	// Write outside of Do so the caller is found at the usual depth
+	first := false
+	g.once.Do(func() {
+		first = true
+	})
+	if first {
+		g.parent.Output(1, g.parent.prefix(LevelInfo), "end group: "+g.name)
+	}
+}
+
+// appendGroupIndent indent the message of the nested group logger
+func appendGroupIndent(buf *colorful.ColorBuffer, depth int) {
+	for i := 0; i < depth; i++ {
+		buf.AppendString(groupIndent)
+	}
+}
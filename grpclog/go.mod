@@ -0,0 +1,22 @@
+module github.com/csturiale/go-log/grpclog
+
+go 1.20
+
+require (
+	github.com/csturiale/go-log v0.0.0
+	github.com/smartystreets/goconvey v1.8.0
+	google.golang.org/grpc v1.58.3
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v1.13.1 // indirect
+	golang.org/x/net v0.12.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/text v0.11.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
+
+replace github.com/csturiale/go-log => ../
@@ -0,0 +1,51 @@
+// gRPC interceptors for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package grpclog
+
+import (
+	"context"
+	"time"
+
+	log "github.com/csturiale/go-log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a server interceptor which log every unary
+// call at info level once it is handled, with the method, code, duration and
+// error fields
+func UnaryServerInterceptor(l *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(l, "unary call", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor which log every
+// streaming call at info level once the stream is closed, with the same
+// fields as UnaryServerInterceptor
+func StreamServerInterceptor(l *log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(l, "stream call", info.FullMethod, start, err)
+		return err
+	}
+}
+
+// logCall write the finished call, the error field is only added when the
+// handler failed
+func logCall(l *log.Logger, msg string, method string, start time.Time, err error) {
+	kv := []interface{}{
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration", log.RoundDuration(time.Since(start)),
+	}
+	if err != nil {
+		kv = append(kv, "error", status.Convert(err).Message())
+	}
+	l.Infow(msg, kv...)
+}
@@ -0,0 +1,92 @@
+// gRPC interceptors for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package grpclog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	log "github.com/csturiale/go-log"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// errBroken is a plain error returned by the handlers
+var errBroken = errors.New("broken")
+
+// out is the output of the singleton logger returned by Init
+var out testWriter
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	Convey("Given logger and unary interceptor", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+		interceptor := UnaryServerInterceptor(l)
+		info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+
+		Convey("When the call succeed", func() {
+			resp, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "resp", nil
+			})
+
+			Convey("It should log the method and OK code", func() {
+				So(resp, ShouldEqual, "resp")
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldStartWith, "[][INFO]  unary call method=/test.Service/Get code=OK duration=")
+				So(out.String(), ShouldNotContainSubstring, "error=")
+			})
+		})
+
+		Convey("When the call failed", func() {
+			_, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, status.Error(codes.NotFound, "no such item")
+			})
+
+			Convey("It should log the code and the error", func() {
+				So(status.Code(err), ShouldEqual, codes.NotFound)
+				So(out.String(), ShouldContainSubstring, " code=NotFound duration=")
+				So(out.String(), ShouldEndWith, " error=\"no such item\"\n")
+			})
+		})
+	})
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	Convey("Given logger and stream interceptor", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+		interceptor := StreamServerInterceptor(l)
+		info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch", IsServerStream: true}
+
+		Convey("When the stream is closed", func() {
+			err := interceptor(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+				return errBroken
+			})
+
+			Convey("It should log the unknown code of a non gRPC error", func() {
+				So(err, ShouldEqual, errBroken)
+				So(out.String(), ShouldStartWith, "[][INFO]  stream call method=/test.Service/Watch code=Unknown duration=")
+				So(out.String(), ShouldEndWith, " error=broken\n")
+			})
+		})
+	})
+}
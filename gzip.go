@@ -0,0 +1,127 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"compress/gzip"
+	"os"
+	"sync"
+	"time"
+)
+
+// Gzip file flush thresholds
+const (
+	gzipFlushSize     = 32 * 1024
+	gzipFlushInterval = time.Second
+)
+
+// gzipFile is a FdWriter that compress the log into a gzip file
+type gzipFile struct {
+	mu      sync.Mutex
+	file    *os.File
+	zw      *gzip.Writer
+	pending int
+	done    chan struct{}
+}
+
+// NewGzipFile returns FdWriter that append gzip compressed log to the file
+// at path with the given compression level (see compress/gzip). The stream
+// is flushed every 32KB and every second so the file stays readable while
+// the application is running. The returned writer implements io.Closer
+// which must be called to finalize the gzip stream.
+func NewGzipFile(path string, level int) (FdWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	zw, err := gzip.NewWriterLevel(file, level)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &gzipFile{
+		file: file,
+		zw:   zw,
+		done: make(chan struct{}),
+	}
+	go w.flusher()
+	return w, nil
+}
+
+// Write compress the data and flush when the size threshold reached
+func (w *gzipFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.zw.Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.pending += n
+	if w.pending >= gzipFlushSize {
+		err = w.flush()
+	}
+	return n, err
+}
+
+// Fd returns the underlying file descriptor
+func (w *gzipFile) Fd() uintptr {
+	return w.file.Fd()
+}
+
+// Flush write pending compressed data to the file
+func (w *gzipFile) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+// Sync write pending compressed data and commit the file to stable storage
+func (w *gzipFile) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close finalize the gzip stream and close the file
+func (w *gzipFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+		return os.ErrClosed
+	default:
+		close(w.done)
+	}
+	if err := w.zw.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// flush pending data, caller must hold the lock
+func (w *gzipFile) flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	w.pending = 0
+	return w.zw.Flush()
+}
+
+// flusher periodically flush pending data until closed
+func (w *gzipFile) flusher() {
+	ticker := time.NewTicker(gzipFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
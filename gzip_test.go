@@ -0,0 +1,41 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGzipFile(t *testing.T) {
+	Convey("Given logger writing to gzip file", t, func() {
+		path := filepath.Join(t.TempDir(), "app.log.gz")
+		out, err := NewGzipFile(path, gzip.BestSpeed)
+		So(err, ShouldBeNil)
+		l := newLogger(Config{Out: out})
+		l.Info("compressed")
+
+		Convey("When the file closed", func() {
+			So(out.(io.Closer).Close(), ShouldBeNil)
+
+			Convey("It should contain the compressed message", func() {
+				f, err := os.Open(path)
+				So(err, ShouldBeNil)
+				defer f.Close()
+				zr, err := gzip.NewReader(f)
+				So(err, ShouldBeNil)
+				data, err := io.ReadAll(zr)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, "[][INFO]  compressed\n")
+			})
+		})
+	})
+}
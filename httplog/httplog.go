@@ -0,0 +1,121 @@
+// HTTP middleware for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/csturiale/go-log"
+)
+
+// RequestIDHeader is the header carrying the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey is the private type of the context key
+type contextKey struct{}
+
+// Middleware returns an HTTP middleware which attach a child of l with the
+// request_id field to the request context, see FromContext. The request ID
+// is taken from the X-Request-ID header or generated, and set on the
+// response. Every request is logged at info level once it is served.
+func Middleware(l *log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			reqLog := l.WithFields(log.Fields{"request_id": id})
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(NewContext(r.Context(), reqLog)))
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			reqLog.Infof("method=%s path=%s status=%d duration=%s",
+				r.Method, r.URL.Path, rw.status, log.RoundDuration(time.Since(start)))
+		})
+	}
+}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *log.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored by Middleware or NewContext, or a
+// logger discarding every line when there is none
+func FromContext(ctx context.Context) *log.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*log.Logger); ok {
+		return l
+	}
+	return log.NewDiscardLogger()
+}
+
+// newRequestID returns a random 16 bytes hex encoded ID
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// responseWriter record the status code written by the handler
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader record the status code and forward it
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write record the implicit 200 status and forward the data
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush record the implicit 200 status and send the buffered data to the
+// client when the original writer support it, for the streaming handlers
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack take over the connection when the original writer support it, e.g.
+// for the websocket handlers, the request is logged with status 101
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the original writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,124 @@
+// HTTP middleware for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package httplog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/csturiale/go-log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// out is the output of the singleton logger returned by Init
+var out testWriter
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given logger and middleware wrapped handler", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+		handler := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			FromContext(r.Context()).Info("handling")
+			w.WriteHeader(http.StatusTeapot)
+		}))
+
+		Convey("When a request with ID served", func() {
+			req := httptest.NewRequest("GET", "/foo", nil)
+			req.Header.Set(RequestIDHeader, "abc")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			Convey("It should log with the request ID", func() {
+				So(out.String(), ShouldStartWith, "[][INFO]  handling request_id=abc\n")
+				So(out.String(), ShouldContainSubstring, "[][INFO]  method=GET path=/foo status=418 duration=")
+				So(rec.Header().Get(RequestIDHeader), ShouldEqual, "abc")
+			})
+		})
+
+		Convey("When a request without ID served", func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			Convey("It should generate the request ID", func() {
+				So(len(rec.Header().Get(RequestIDHeader)), ShouldEqual, 32)
+				So(out.String(), ShouldContainSubstring, "request_id="+rec.Header().Get(RequestIDHeader))
+			})
+		})
+	})
+}
+
+// hijackRecorder is a recorder supporting http.Hijacker
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+// Hijack record the call and returns no connection
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriter(t *testing.T) {
+	Convey("Given logger and middleware wrapped streaming handler", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+		var hijackErr error
+		handler := Middleware(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ws" {
+				_, _, hijackErr = w.(http.Hijacker).Hijack()
+				return
+			}
+			w.Write([]byte("event"))
+			w.(http.Flusher).Flush()
+		}))
+
+		Convey("It should forward Flush to the original writer", func() {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/events", nil))
+			So(rec.Flushed, ShouldBeTrue)
+			So(out.String(), ShouldContainSubstring, "method=GET path=/events status=200 ")
+		})
+
+		Convey("It should forward Hijack to the original writer", func() {
+			rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+			So(hijackErr, ShouldBeNil)
+			So(rec.hijacked, ShouldBeTrue)
+			So(out.String(), ShouldContainSubstring, "method=GET path=/ws status=101 ")
+		})
+
+		Convey("It should fail to hijack when the original writer cannot", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+			So(hijackErr, ShouldEqual, http.ErrNotSupported)
+		})
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	Convey("Given context without logger", t, func() {
+		Convey("It should return a usable logger", func() {
+			So(FromContext(context.Background()), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,137 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journalSocket is the path of the journald native protocol socket
+var journalSocket = "/run/systemd/journal/socket"
+
+// JournalWriter send the lines to journald with the native protocol, keeping
+// the level as PRIORITY and the fields as journal fields
+type JournalWriter struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+	buf  []byte
+}
+
+// NewJournalWriter connect to the local journald socket and returns a writer
+// sending every line as a journal entry. It returns an error when journald is
+// not running, the caller can then fall back to stderr. The text format and
+// color of the logger are not used, the entry carry the message, PRIORITY,
+// SYSLOG_IDENTIFIER from the prefix, CODE_FILE, CODE_LINE and CODE_FUNC when
+// the caller info is on, and the fields with upper-cased keys.
+func NewJournalWriter() (FdWriter, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournalWriter{conn: conn}, nil
+}
+
+// Write send p as the message of an informational entry since its level is
+// unknown
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = w.buf[:0]
+	w.buf = appendJournalField(w.buf, "MESSAGE", bytes.TrimSuffix(p, []byte("\n")))
+	w.buf = appendJournalField(w.buf, "PRIORITY", []byte("6"))
+	if _, err := w.conn.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeRecord send the record as a journal entry, the logger call it instead
+// of Write
+func (w *JournalWriter) writeRecord(c *Config, r *record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = w.buf[:0]
+	w.buf = appendJournalField(w.buf, "MESSAGE", bytes.TrimSuffix(r.data, []byte("\n")))
+	priority := 6
+	if r.prefix.Level.valid() {
+		priority = gelfLevels[r.prefix.Level.index()]
+	}
+	w.buf = appendJournalField(w.buf, "PRIORITY", strconv.AppendInt(nil, int64(priority), 10))
+	if r.name != "" {
+		w.buf = appendJournalField(w.buf, "SYSLOG_IDENTIFIER", []byte(r.name))
+	}
+	if r.prefix.File {
+		w.buf = appendJournalField(w.buf, "CODE_FILE", []byte(r.file))
+		w.buf = appendJournalField(w.buf, "CODE_LINE", strconv.AppendInt(nil, int64(r.line), 10))
+		w.buf = appendJournalField(w.buf, "CODE_FUNC", []byte(r.fn))
+	}
+	for _, field := range r.fields {
+		if key := journalKey(field.Key); key != "" {
+			w.buf = appendJournalField(w.buf, key, []byte(journalValue(c.fieldValue(field.Value))))
+		}
+	}
+	_, err := w.conn.Write(w.buf)
+	return err
+}
+
+// Fd returns invalid file descriptor so the entries are never colored
+func (w *JournalWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// Close close the connection to journald
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.Close()
+}
+
+// appendJournalField append the field in the native protocol, the values with
+// a newline are written with their 64-bit little endian length instead
+func appendJournalField(b []byte, key string, value []byte) []byte {
+	b = append(b, key...)
+	if bytes.IndexByte(value, '\n') < 0 {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalKey returns the field key as a valid journal field name: upper case
+// letters, digits and underscores, not starting with an underscore which is
+// reserved for the trusted fields, or an empty string when nothing is left
+func journalKey(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	name = bytes.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return string(name)
+}
+
+// journalValue returns the raw text of the field value, the strings are not
+// quoted since the journal fields are not space separated
+func journalValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case error:
+		return val.Error()
+	}
+	return textValue(v)
+}
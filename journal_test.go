@@ -0,0 +1,75 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package log
+
+import (
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJournalWriter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram socket is not supported")
+	}
+	Convey("Given fake journald socket", t, func() {
+		path := filepath.Join(t.TempDir(), "socket")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		So(err, ShouldBeNil)
+		defer conn.Close()
+		defer func(socket string) { journalSocket = socket }(journalSocket)
+		journalSocket = path
+		receive := func() string {
+			buf := make([]byte, 4096)
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, err := conn.Read(buf)
+			So(err, ShouldBeNil)
+			return string(buf[:n])
+		}
+
+		w, err := NewJournalWriter()
+		So(err, ShouldBeNil)
+		defer w.(*JournalWriter).Close()
+		l := newLogger(Config{Out: w, Prefix: "app", Color: true})
+
+		Convey("It should send the level, prefix and fields as journal fields", func() {
+			l.WithoutCaller().WithFields(Fields{"user-id": 42, "_trusted": "x"}).Warn("disk low")
+			So(receive(), ShouldEqual, "MESSAGE=disk low\nPRIORITY=4\nSYSLOG_IDENTIFIER=app\nTRUSTED=x\nUSER_ID=42\n")
+		})
+
+		Convey("It should send the caller info", func() {
+			l.WithCallerForAll().Info("hello")
+			entry := receive()
+			So(entry, ShouldContainSubstring, "\nCODE_FILE=journal_test.go\nCODE_LINE=")
+			So(entry, ShouldContainSubstring, "\nCODE_FUNC=github.com/csturiale/go-log.TestJournalWriter")
+		})
+
+		Convey("It should send the multi-line value with its length", func() {
+			l.WithoutCaller().Error("first\nsecond")
+			So(receive(), ShouldStartWith, "MESSAGE\n\x0c\x00\x00\x00\x00\x00\x00\x00first\nsecond\nPRIORITY=3\n")
+		})
+
+		Convey("It should send the plain writes as informational entries", func() {
+			w.Write([]byte("raw line\n"))
+			So(receive(), ShouldEqual, "MESSAGE=raw line\nPRIORITY=6\n")
+		})
+	})
+
+	Convey("Given no journald socket", t, func() {
+		defer func(socket string) { journalSocket = socket }(journalSocket)
+		journalSocket = filepath.Join(t.TempDir(), "missing")
+
+		Convey("It should return an error to fall back on", func() {
+			w, err := NewJournalWriter()
+			So(err, ShouldNotBeNil)
+			So(w, ShouldBeNil)
+		})
+	})
+}
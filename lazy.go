@@ -0,0 +1,50 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// lazyStringer call fn only when the value is formatted
+type lazyStringer struct {
+	fn func() string
+}
+
+// Stringer returns a value formatted as the result of fn, which is called
+// only when the line is actually written, e.g. for an expensive dump passed to
+// Debugf while the debug output is off
+func Stringer(fn func() string) fmt.Stringer {
+	return lazyStringer{fn: fn}
+}
+
+// String returns the result of fn
+func (s lazyStringer) String() string {
+	return s.fn()
+}
+
+// MarshalJSON encode the result of fn as JSON string for the fields
+func (s lazyStringer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.fn())
+}
+
+// lazyError call fn only when the error message is formatted
+type lazyError struct {
+	fn func() error
+}
+
+// LazyErr returns an error with the message of the error returned by fn,
+// which is called only when the line is actually written
+func LazyErr(fn func() error) error {
+	return lazyError{fn: fn}
+}
+
+// Error returns the message of the error returned by fn, or <nil>
+func (e lazyError) Error() string {
+	if err := e.fn(); err != nil {
+		return err.Error()
+	}
+	return "<nil>"
+}
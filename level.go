@@ -0,0 +1,318 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// Level define the severity of a log message, lower level is more verbose
+type Level int
+
+// Available log levels, the zero value is LevelInfo
+const (
+	LevelTrace Level = iota - 2
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// numLevels is the number of known levels
+const numLevels = int(LevelFatal-LevelTrace) + 1
+
+// valid check whether the level is one of the known levels
+func (lv Level) valid() bool {
+	return lv >= LevelTrace && lv <= LevelFatal
+}
+
+// index returns the zero based position of a known level
+func (lv Level) index() int {
+	return int(lv - LevelTrace)
+}
+
+// String returns the upper case name of the level
+func (lv Level) String() string {
+	switch lv {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelFatal:
+		return "FATAL"
+	}
+	return "UNKNOWN"
+}
+
+// LevelStyle define how the level tag is written in text format
+type LevelStyle int
+
+// Available level styles, the zero value is LevelStyleFull
+const (
+	// LevelStyleFull write the prefix tag such as [WARN] padded to the
+	// longest tag
+	LevelStyleFull LevelStyle = iota
+	// LevelStyleShort write a three letters tag such as [WRN]
+	LevelStyleShort
+	// LevelStyleLetter write a single letter tag such as [W]
+	LevelStyleLetter
+)
+
+// levelTagWidth is the width of the full level tag including the padding
+const levelTagWidth = len("[FATAL] ")
+
+// Compact level tags indexed by level
+var (
+	shortTags  = [numLevels][]byte{[]byte("[TRC]"), []byte("[DBG]"), []byte("[INF]"), []byte("[WRN]"), []byte("[ERR]"), []byte("[FTL]")}
+	letterTags = [numLevels][]byte{[]byte("[T]"), []byte("[D]"), []byte("[I]"), []byte("[W]"), []byte("[E]"), []byte("[F]")}
+)
+
+// WithLevelStyle set how the level tag is written in text format
+func (l *Logger) WithLevelStyle(style LevelStyle) *Logger {
+	return l.update(func(c *Config) {
+		c.LevelStyle = style
+	})
+}
+
+// LevelFormat define how the level tag is decorated in text format
+type LevelFormat int
+
+// Available level formats, the zero value is LevelFormatBracketed
+const (
+	// LevelFormatBracketed write the tag in brackets such as [INFO]
+	LevelFormatBracketed LevelFormat = iota
+	// LevelFormatPlain write the level name followed by a colon such as INFO:
+	LevelFormatPlain
+	// LevelFormatPadded write the level name padded to 5 characters
+	LevelFormatPadded
+	// LevelFormatNone write no level tag at all
+	LevelFormatNone
+)
+
+// levelNameWidth is the width of the padded level name
+const levelNameWidth = len("FATAL")
+
+// WithLevelFormat set how the level tag is decorated in text format
+func (l *Logger) WithLevelFormat(format LevelFormat) *Logger {
+	return l.update(func(c *Config) {
+		c.LevelFormat = format
+	})
+}
+
+// WithHideLevel write no level tag in text format, e.g. for a user-facing
+// command line tool, while the lines are still filtered by level. The prefix,
+// timestamp and caller are written as usual.
+func (l *Logger) WithHideLevel() *Logger {
+	return l.update(func(c *Config) {
+		c.HideLevel = true
+	})
+}
+
+// WithoutHideLevel write the level tag again in the LevelFormat
+func (l *Logger) WithoutHideLevel() *Logger {
+	return l.update(func(c *Config) {
+		c.HideLevel = false
+	})
+}
+
+// levelFormat returns the level format of the text lines, LevelFormatNone
+// when the level is hidden
+func (c *Config) levelFormat() LevelFormat {
+	if c.HideLevel {
+		return LevelFormatNone
+	}
+	return c.LevelFormat
+}
+
+// appendLevelTag write the level tag of the prefix in the style and format
+// followed by the padding
+func appendLevelTag(buf *colorful.ColorBuffer, style LevelStyle, format LevelFormat, prefix Prefix, color bool) {
+	if format == LevelFormatNone {
+		return
+	}
+	tag := prefix.Plain
+	switch {
+	case style == LevelStyleShort && prefix.Level.valid():
+		tag = shortTags[prefix.Level.index()]
+	case style == LevelStyleLetter && prefix.Level.valid():
+		tag = letterTags[prefix.Level.index()]
+	}
+	if format != LevelFormatBracketed {
+		tag = bytes.TrimSuffix(bytes.TrimPrefix(tag, []byte("[")), []byte("]"))
+	}
+	if color && style == LevelStyleFull && format == LevelFormatBracketed {
+		buf.Append(prefix.Color)
+	} else if color {
+		// Reuse the color escape in front of the plain tag
+		buf.Append(levelColor(prefix))
+		buf.Append(tag)
+		buf.Off()
+	} else {
+		buf.Append(tag)
+	}
+	// Pad the tag so the messages start at the same column
+	width := len(tag)
+	switch {
+	case format == LevelFormatPlain:
+		buf.AppendByte(':')
+	case format == LevelFormatPadded:
+		for ; width < levelNameWidth; width++ {
+			buf.AppendByte(' ')
+		}
+	case style == LevelStyleFull:
+		for ; width < levelTagWidth-1; width++ {
+			buf.AppendByte(' ')
+		}
+	}
+	if len(tag) == 0 || tag[len(tag)-1] != ' ' || format == LevelFormatPlain {
+		buf.AppendByte(' ')
+	}
+}
+
+// levelColor returns the color escape in front of the plain tag of the
+// colored prefix
+func levelColor(prefix Prefix) []byte {
+	if i := bytes.Index(prefix.Color, prefix.Plain); i > 0 {
+		return prefix.Color[:i]
+	}
+	return nil
+}
+
+// WithLevelWriter write the lines of the level to w instead of Out, a nil w
+// restore Out, unknown level is ignored
+func (l *Logger) WithLevelWriter(level Level, w FdWriter) *Logger {
+	if !level.valid() {
+		return l
+	}
+	return l.update(func(c *Config) {
+		c.writers[level.index()] = w
+	})
+}
+
+// WithErrorsToStderr write the error and fatal lines to stderr, the other
+// levels are still written to Out
+func (l *Logger) WithErrorsToStderr() *Logger {
+	return l.WithLevelWriter(LevelError, os.Stderr).WithLevelWriter(LevelFatal, os.Stderr)
+}
+
+// WithLevel set the minimum level that will be written to the output
+func (l *Logger) WithLevel(level Level) *Logger {
+	return l.update(func(c *Config) {
+		c.setLevel(level)
+	})
+}
+
+// IsEnabled check whether message with the given level will be written
+func (l *Logger) IsEnabled(level Level) bool {
+	return level >= l.config.Load().level()
+}
+
+// GetLevel returns the effective minimum level, LevelTrace when the debug
+// output is turned on
+func (l *Logger) GetLevel() Level {
+	return l.config.Load().level()
+}
+
+// level returns the effective minimum level
+func (c *Config) level() Level {
+	if c.Debug && c.Level > LevelTrace {
+		return LevelTrace
+	}
+	return c.Level
+}
+
+// setLevel clamp and store the minimum level
+func (c *Config) setLevel(level Level) {
+	if level < LevelTrace {
+		level = LevelTrace
+	} else if level > LevelFatal {
+		level = LevelFatal
+	}
+	c.Level = level
+	c.Debug = false
+}
+
+// shiftLevel move the effective minimum level by delta steps
+func (l *Logger) shiftLevel(delta int) {
+	l.update(func(c *Config) {
+		c.setLevel(c.level() + Level(delta))
+	})
+}
+
+// packageLevel is a minimum level rule for a package path prefix
+type packageLevel struct {
+	prefix string
+	level  Level
+}
+
+// SetLevelForPackage set the minimum level for messages logged from packages
+// matching the package path prefix (e.g. "example.com/app/payment" also
+// match "example.com/app/payment/fraud"). The longest matching prefix wins,
+// the logger level applies when no rule matches.
+func (l *Logger) SetLevelForPackage(pkgPrefix string, level Level) *Logger {
+	return l.update(func(c *Config) {
+		// Copy on write since the rules may be shared with clones
+		rules := make([]packageLevel, 0, len(c.packageLevels)+1)
+		for _, rule := range c.packageLevels {
+			if rule.prefix != pkgPrefix {
+				rules = append(rules, rule)
+			}
+		}
+		c.packageLevels = append(rules, packageLevel{prefix: pkgPrefix, level: level})
+	})
+}
+
+// mayEnable check whether message with the given level could be written by
+// any package, it is used before the caller is known
+func (l *Logger) mayEnable(level Level) bool {
+	return l.config.Load().mayEnable(level)
+}
+
+// mayEnable check whether message with the given level could be written by
+// any package of the configuration
+func (c *Config) mayEnable(level Level) bool {
+	if level >= c.level() {
+		return true
+	}
+	for _, rule := range c.packageLevels {
+		if level >= rule.level {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnabledFor check whether message with the given level logged from the
+// function fn will be written
+func (c *Config) isEnabledFor(level Level, fn string) bool {
+	pkg := packageName(fn)
+	min, longest := c.level(), -1
+	for _, rule := range c.packageLevels {
+		if len(rule.prefix) > longest && (pkg == rule.prefix || strings.HasPrefix(pkg, rule.prefix+"/")) {
+			min, longest = rule.level, len(rule.prefix)
+		}
+	}
+	return level >= min
+}
+
+// packageName returns the package path of a fully qualified function name
+// such as "example.com/app/payment.(*Service).Charge"
+func packageName(fn string) string {
+	slash := strings.LastIndexByte(fn, '/') + 1
+	if dot := strings.IndexByte(fn[slash:], '.'); dot >= 0 {
+		return fn[:slash+dot]
+	}
+	return fn
+}
@@ -0,0 +1,69 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"os"
+	"runtime"
+)
+
+// WithLifecycleEvents log "logger initialized" right away and "logger closed"
+// when the logger is garbage collected. Both lines include the host and pid
+// fields to identify the application instance.
+func (l *Logger) WithLifecycleEvents() *Logger {
+	l.cmu.Lock()
+	c := *l.config.Load()
+	enabled := c.LifecycleEvents
+	c.LifecycleEvents = true
+	l.config.Store(&c)
+	l.cmu.Unlock()
+	if !enabled {
+		l.startLifecycle()
+	}
+	return l
+}
+
+// startLifecycle log the initialized event and register the closed event
+func (l *Logger) startLifecycle() {
+	l.lifecycleEvent("logger initialized")
+	runtime.SetFinalizer(l, func(l *Logger) {
+		l.lifecycleEvent("logger closed")
+	})
+}
+
+// lifecycleEvent log msg at info level with the instance identification
+func (l *Logger) lifecycleEvent(msg string) {
+	l.output(2, l.prefix(LevelInfo), []byte(msg), lifecycleFields(), nil)
+}
+
+// closedEvent write the closed event like lifecycleEvent, caller must hold
+// the write lock
+func (l *Logger) closedEvent() error {
+	c := l.config.Load()
+	if c.Quiet || !c.mayEnable(LevelInfo) {
+		return nil
+	}
+	prefix := l.prefix(LevelInfo)
+	prefix.File = false
+	buf := getBuffer()
+	defer putBuffer(buf)
+	return l.write(buf, c, &record{
+		now:    c.now(),
+		prefix: prefix,
+		data:   []byte("logger closed"),
+		fields: mergeFields(c.fields, lifecycleFields()),
+	})
+}
+
+// lifecycleFields returns the host and pid fields of the lifecycle events
+func lifecycleFields() []Field {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "<unknown host>"
+	}
+	return []Field{
+		{Key: "host", Value: hostname},
+		{Key: "pid", Value: os.Getpid()},
+	}
+}
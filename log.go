@@ -4,14 +4,19 @@
 package log
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/csturiale/go-log/colorful"
 )
@@ -23,190 +28,671 @@ type FdWriter interface {
 	Fd() uintptr
 }
 type Config struct {
-	Color     bool
-	Out       FdWriter
-	Debug     bool
-	Timestamp bool
-	Quiet     bool
-	Prefix    string
+	Color bool
+	// ColorScope select the colored parts of the line, see ColorAll
+	ColorScope ColorScope
+	// ColorWholeLine color the whole line with the level color, it has no
+	// effect with ColorNone
+	ColorWholeLine bool
+	// ColorMessage color the message text with the level color, the other
+	// parts keep their own color
+	ColorMessage bool
+	Out          FdWriter
+	Debug        bool
+	Level        Level
+	Timestamp    bool
+	// TimePrecision add the fraction of second to the timestamp
+	TimePrecision TimePrecision
+	// Caller override the caller info setting of the prefixes
+	Caller CallerMode
+	// CallerFormat set how the caller info is written in text format
+	CallerFormat CallerFormat
+	// MinCallerWidth hide the caller info when the output is a terminal
+	// narrower than this many columns, zero means never
+	MinCallerWidth int
+	// Clock returns the time of the log lines, nil means time.Now
+	Clock func() time.Time
+	// Location is the time zone of the timestamp, nil means the time zone of
+	// the clock which is local for time.Now
+	Location *time.Location
+	// ElapsedSince replace the date and time of the text timestamp with the
+	// time elapsed since then, such as +00:01.234
+	ElapsedSince time.Time
+	Quiet        bool
+	Prefix       string
+	// MultiLine select how the continuation lines of a multi-line message are
+	// written in text format, see MultiLineIndent
+	MultiLine MultiLineMode
+	// LevelStyle select how the level tag is written in text format, see
+	// LevelStyleFull
+	LevelStyle LevelStyle
+	// LevelFormat select how the level tag is decorated in text format, see
+	// LevelFormatBracketed
+	LevelFormat LevelFormat
+	// HideLevel write no level tag in text format, like LevelFormatNone,
+	// the lines are still filtered by level
+	HideLevel bool
+	// PrefixFunc is called on every write to get the logger prefix, Prefix
+	// is used when it is nil
+	PrefixFunc func() string
+	Format     Format
+	// JSONKeys rename the standard members of the JSON lines
+	JSONKeys JSONKeys
+	// DurationFormat select how the time.Duration fields are written in
+	// JSON, see DurationString
+	DurationFormat DurationFormat
+	// BytesEncoding select how the []byte fields are written, see BytesHex
+	BytesEncoding BytesEncoding
+	GELFHost      string
+	// GELFTCP terminate the GELF messages with a null byte, see WithGELFTCP
+	GELFTCP  bool
+	Hostname string
+	PID      int
+	// GoroutineID add gid=<id> to each log line, slow and meant for
+	// development only
+	GoroutineID bool
+	// SequenceID add seq=<n> to each log line, n is counted by the logger
+	// from 1 and wrap around after math.MaxUint64
+	SequenceID bool
+	// HashChain add prev=<hash> of the previous line to each log line, see
+	// WithHashChain
+	HashChain bool
+	// DedupWindow collapse identical consecutive messages within the window
+	DedupWindow time.Duration
+	// FieldColumn pad the message in text format so the structured fields
+	// start at this column, zero disables the padding
+	FieldColumn int
+	// AlignedFields pad the structured fields in text format into columns
+	// computed over the last AlignmentWindow lines (default to 10)
+	AlignedFields   bool
+	AlignmentWindow int
+	// AutoStackOnError attach the caller stack to Error and Fatal lines as the
+	// stack field, limited to StackDepth frames (default to 32)
+	AutoStackOnError bool
+	StackDepth       int
+	// NoTrailingNewline stop terminating every line with a newline, e.g. when
+	// the transport frame the messages itself
+	NoTrailingNewline bool
+	// LifecycleEvents log an event when the logger is initialized by Init and
+	// when it is closed
+	LifecycleEvents bool
+	// MaxMessageLen truncate longer message to this many bytes, zero means
+	// unlimited
+	MaxMessageLen int
+	// ExitFunc is called by Fatal after the message is written, nil means
+	// os.Exit
+	ExitFunc func(code int)
+	// ErrorHandler is called when writing to Out fails, nil means no-op
+	ErrorHandler func(err error)
+	// MetricsObserver is called once for every line which is not suppressed
+	// by the level, quiet or deduplication, even if the write fails
+	MetricsObserver func(level Level)
+
+	// packageLevels is the minimum level rules by package path prefix
+	packageLevels []packageLevel
+	// prefixes is the logger own copy of the level prefixes
+	prefixes [numLevels]Prefix
+	// writers is the output by level, Out is used when nil
+	writers [numLevels]FdWriter
+	// redactors mask the sensitive parts of the message, see WithRedaction
+	redactors []func([]byte) []byte
+	// filters drop the line when any returns false, see AddFilter
+	filters []func(level Level, msg string) bool
+	// callerFilter drop the line when it returns false, see WithCallerFilter
+	callerFilter func(file, fn string) bool
+	// fields is the structured fields appended to every line
+	fields []Field
+	// predicate skip the line when it returns false, see If
+	predicate func() bool
+	// groupDepth is the number of nested groups, see Group
+	groupDepth int
+	// timestampColor and callerColor replace the default colors, see
+	// WithTheme
+	timestampColor []byte
+	callerColor    []byte
+	// entryCallback and entries receive every written line, see
+	// WithEntryCallback and WithEntryBuffering
+	entryCallback func(entry LogEntry)
+	entries       *entryRing
+	// middlewares is the chain every line goes through, see Use
+	middlewares []Middleware
+	// narrow is set when the output is narrower than MinCallerWidth
+	narrow bool
 }
 
 // Logger struct define the underlying storage for single logger
 type Logger struct {
-	mu     sync.RWMutex
-	config Config
-	buf    colorful.ColorBuffer
+	*sharedState
+	cmu    sync.Mutex
+	config atomic.Pointer[Config]
+	repeat repeatState
+	align  *alignState
+	group  *groupState
+}
+
+// sharedState is the state shared by a logger and its child loggers, so the
+// lines written through any of them are serialized, numbered and chained
+// together
+type sharedState struct {
+	mu  sync.RWMutex
+	seq atomic.Uint64
+	// chains hold the hash chain of every output, guarded by mu
+	chains map[io.Writer]*[sha256.Size]byte
+	// closed is set by Close, guarded by mu
+	closed bool
 }
 
-// Prefix struct define plain and Color byte
+// Prefix struct define plain and Color byte. It is the stable extension
+// point of the logger together with Output, build it with NewPrefixDef to log
+// at a custom level. The fields are not renamed or removed in a future
+// version, the new fields always default to the current behavior.
 type Prefix struct {
+	// Plain is the level tag written without color, such as [INFO]
 	Plain []byte
+	// Color is the level tag written when the color is on, with its escape
+	// sequences
 	Color []byte
-	File  bool
+	// File print the caller info, see also WithCaller
+	File bool
+	// Level is the severity used by the level filters and the structured
+	// formats, the zero value is LevelInfo
+	Level Level
 }
 
 var (
-	// Plain prefix template
-	plainFatal = []byte("[FATAL] ")
-	plainError = []byte("[ERROR] ")
-	plainWarn  = []byte("[WARN]  ")
-	plainInfo  = []byte("[INFO]  ")
-	plainDebug = []byte("[DEBUG] ")
-	plainTrace = []byte("[TRACE] ")
+	// builtinPrefixes initialize the deprecated package level prefixes
+	builtinPrefixes = DefaultPrefixes()
 
 	// FatalPrefix show fatal prefix
-	FatalPrefix = Prefix{
-		Plain: plainFatal,
-		Color: colorful.Red(plainFatal),
-		File:  true,
-	}
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	FatalPrefix = builtinPrefixes.Fatal
 
 	// ErrorPrefix show error prefix
-	ErrorPrefix = Prefix{
-		Plain: plainError,
-		Color: colorful.Red(plainError),
-		File:  true,
-	}
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	ErrorPrefix = builtinPrefixes.Error
 
 	// WarnPrefix show warn prefix
-	WarnPrefix = Prefix{
-		Plain: plainWarn,
-		Color: colorful.Orange(plainWarn),
-	}
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	WarnPrefix = builtinPrefixes.Warn
 
 	// InfoPrefix show info prefix
-	InfoPrefix = Prefix{
-		Plain: plainInfo,
-		Color: colorful.Green(plainInfo),
-	}
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	InfoPrefix = builtinPrefixes.Info
 
 	// DebugPrefix show info prefix
-	DebugPrefix = Prefix{
-		Plain: plainDebug,
-		Color: colorful.Purple(plainDebug),
-		File:  true,
-	}
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	DebugPrefix = builtinPrefixes.Debug
 
 	// TracePrefix show info prefix
-	TracePrefix = Prefix{
-		Plain: plainTrace,
-		Color: colorful.Cyan(plainTrace),
-	}
-	logger *Logger
+	//
+	// Deprecated: changing it affect every logger created afterwards, use
+	// DefaultPrefixes and WithPrefixes instead.
+	TracePrefix = builtinPrefixes.Trace
+
+	// logger is the single instance returned by Init, also used by the
+	// package level functions, see SetDefault
+	logger atomic.Pointer[Logger]
+	// initMu serialize Init so a single instance is ever created
+	initMu sync.Mutex
 )
 
-// Init returns single logger instance with predefined writer output and
-// automatically detect terminal coloring support
+// Init returns single logger instance with predefined writer output, the
+// color is turned off when the NO_COLOR environment variable is set or TERM
+// is dumb
 func Init(config Config) (*Logger, error) {
 	if config.Out == nil {
 		return nil, errors.New("config.out is a mandatory field")
 	}
-	if logger == nil {
-		logger = newLogger(config)
+	initMu.Lock()
+	defer initMu.Unlock()
+	if l := logger.Load(); l != nil {
+		return l, nil
+	}
+	// NO_COLOR and TERM=dumb win over the configuration
+	if noColor() || dumbTerminal() {
+		config.Color = false
+	}
+	l := newLogger(config)
+	if config.LifecycleEvents {
+		l.startLifecycle()
 	}
-	return logger, nil
+	logger.Store(l)
+	return l, nil
 }
 
 // newLogger returns newLogger Logger instance with predefined writer output and
 // automatically detect terminal coloring support
 func newLogger(config Config) *Logger {
-	return &Logger{
-		config: config,
+	for i, prefix := range defaultPrefixes() {
+		if config.prefixes[i].Plain == nil {
+			config.prefixes[i] = prefix
+		}
+	}
+	config.detectWidth()
+	l := &Logger{
+		sharedState: &sharedState{},
+		align:       &alignState{},
+	}
+	l.config.Store(&config)
+	return l
+}
+
+// update apply fn to a copy of the configuration and swap it in, so the
+// readers never need a lock to get a consistent configuration snapshot
+func (l *Logger) update(fn func(c *Config)) *Logger {
+	l.cmu.Lock()
+	defer l.cmu.Unlock()
+	config := *l.config.Load()
+	fn(&config)
+	l.config.Store(&config)
+	return l
+}
+
+// now returns the current time of the configured clock in the configured
+// location
+func (c *Config) now() time.Time {
+	now := time.Now()
+	if c.Clock != nil {
+		now = c.Clock()
+	}
+	if c.Location != nil {
+		now = now.In(c.Location)
+	}
+	return now
+}
+
+// defaultPrefixes returns the package level prefixes indexed by level
+func defaultPrefixes() [numLevels]Prefix {
+	return [numLevels]Prefix{
+		TracePrefix,
+		DebugPrefix,
+		InfoPrefix,
+		WarnPrefix,
+		ErrorPrefix,
+		FatalPrefix,
+	}
+}
+
+// prefix returns the logger copy of the level prefix
+func (l *Logger) prefix(level Level) Prefix {
+	return l.config.Load().prefixes[level.index()]
+}
+
+// CallerMode override the caller info setting of the prefixes
+type CallerMode int
+
+// Available caller modes, the zero value is CallerDefault
+const (
+	// CallerDefault follow the File setting of each prefix
+	CallerDefault CallerMode = iota
+	// CallerOn print the caller info for every level
+	CallerOn
+	// CallerOff never print the caller info
+	CallerOff
+)
+
+// WithCaller print the caller info for every level, whatever the prefix
+// setting
+func (l *Logger) WithCaller() *Logger {
+	return l.update(func(c *Config) {
+		c.Caller = CallerOn
+	})
+}
+
+// WithoutCaller never print the caller info, whatever the prefix setting
+func (l *Logger) WithoutCaller() *Logger {
+	return l.update(func(c *Config) {
+		c.Caller = CallerOff
+	})
+}
+
+// WithDefaultCaller restore the caller info setting of each prefix
+func (l *Logger) WithDefaultCaller() *Logger {
+	return l.update(func(c *Config) {
+		c.Caller = CallerDefault
+	})
+}
+
+// WithCallerForAll turn on caller info output for every level
+func (l *Logger) WithCallerForAll() *Logger {
+	return l.update(func(c *Config) {
+		for i := range c.prefixes {
+			c.prefixes[i].File = true
+		}
+	})
+}
+
+// WithCallerForLevel turn on or off caller info output for a single level,
+// unknown level is ignored
+func (l *Logger) WithCallerForLevel(level Level, enabled bool) *Logger {
+	if !level.valid() {
+		return l
 	}
+	return l.update(func(c *Config) {
+		c.prefixes[level.index()].File = enabled
+	})
+}
+
+// Clone returns an independent copy of the logger with its own
+// configuration, write lock, sequence counter, hash chains and field
+// alignment state, only the output writer is shared with the original logger
+func (l *Logger) Clone() *Logger {
+	return newLogger(*l.config.Load())
+}
+
+// child returns a copy of the logger with its own configuration sharing the
+// write lock, sequence counter, hash chains and field alignment state, so the
+// lines of the child loggers are serialized, numbered and chained with the
+// lines of the parent
+func (l *Logger) child() *Logger {
+	child := newLogger(*l.config.Load())
+	child.sharedState = l.sharedState
+	child.align = l.align
+	return child
 }
 
 // WithColor explicitly turn on colorful features on the log
 func (l *Logger) WithColor() *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.config.Color = true
-	return l
+	return l.update(func(c *Config) {
+		c.Color = true
+	})
 }
 
 // WithoutColor explicitly turn off colorful features on the log
 func (l *Logger) WithoutColor() *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.config.Color = false
-	return l
+	return l.update(func(c *Config) {
+		c.Color = false
+	})
 }
 
 // WithDebug turn on debugging output on the log to reveal Debug and trace level
 func (l *Logger) WithDebug() *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.config.Debug = true
-	return l
+	return l.update(func(c *Config) {
+		c.Debug = true
+	})
 }
 
 // WithoutDebug turn off debugging output on the log
 func (l *Logger) WithoutDebug() *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.config.Debug = false
-	return l
+	return l.update(func(c *Config) {
+		c.Debug = false
+		if c.Level < LevelInfo {
+			c.Level = LevelInfo
+		}
+	})
 }
 
 // IsDebug check the state of debugging output
 func (l *Logger) IsDebug() bool {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.config.Debug
+	return l.IsEnabled(LevelDebug)
 }
 
-// WithTimestamp turn on Timestamp output on the log
-func (l *Logger) WithTimestamp() *Logger {
+// IsColor check whether the colorful features are turned on
+func (l *Logger) IsColor() bool {
+	return l.config.Load().Color
+}
+
+// IsTimestamp check whether the Timestamp is written on the log
+func (l *Logger) IsTimestamp() bool {
+	return l.config.Load().Timestamp
+}
+
+// GetConfig returns a copy of the current configuration, e.g. to display it
+// on a debug endpoint. Changing the copy has no effect on the logger.
+func (l *Logger) GetConfig() Config {
+	// The setters copy the configuration before changing it, so the copy
+	// never see the later changes
+	return *l.config.Load()
+}
+
+// CopyConfig returns a copy of the current configuration without the
+// writers, Out is nil so the copy cannot be used to write around the logger
+// serialization
+func (l *Logger) CopyConfig() Config {
+	config := l.GetConfig()
+	config.Out = nil
+	config.writers = [numLevels]FdWriter{}
+	return config
+}
+
+// Observe call fn with a consistent snapshot of the configuration, so several
+// fields can be read together without racing with the setters. Changing the
+// copy has no effect on the logger.
+func (l *Logger) Observe(fn func(c Config)) {
+	fn(*l.config.Load())
+}
+
+// SetOutput replace the output writer, the lines being written complete on
+// the previous writer before it returns and its write buffer is flushed
+func (l *Logger) SetOutput(w FdWriter) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.config.Timestamp = true
-	return l
+	return l.update(func(c *Config) {
+		stopBuffer(c, w)
+		c.Out = w
+		c.detectWidth()
+	})
 }
 
-// WithoutTimestamp turn off Timestamp output on the log
-func (l *Logger) WithoutTimestamp() *Logger {
+// WithOutput replace the output writer like SetOutput and detect again whether
+// the new writer should be colored, see DetectColorSupport
+func (l *Logger) WithOutput(w FdWriter) *Logger {
+	color := w != nil && DetectColorSupport(w.Fd())
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.config.Timestamp = false
-	return l
+	return l.update(func(c *Config) {
+		stopBuffer(c, w)
+		c.Out = w
+		c.Color = color
+		c.detectWidth()
+	})
 }
 
-// Quiet turn off all log output
-func (l *Logger) Quiet() *Logger {
+// GetOutput returns the current output writer
+func (l *Logger) GetOutput() FdWriter {
+	return l.config.Load().Out
+}
+
+// SetPrefix replace the logger prefix, e.g. with a version or environment
+// known only after the logger is initialized. The lines being written complete
+// with the previous prefix before it returns.
+func (l *Logger) SetPrefix(prefix string) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.config.Quiet = true
-	return l
+	return l.update(func(c *Config) {
+		c.Prefix = prefix
+	})
+}
+
+// Named returns a child logger with the name segment
+// appended to the prefix after a dot, such as myapp.db.query. The prefix is
+// the name itself when empty.
+func (l *Logger) Named(name string) *Logger {
+	return l.child().update(func(c *Config) {
+		if c.Prefix != "" {
+			c.Prefix += "."
+		}
+		c.Prefix += name
+	})
+}
+
+// GetPrefix returns the current static prefix, the dynamic prefix function is
+// not called
+func (l *Logger) GetPrefix() string {
+	return l.config.Load().Prefix
+}
+
+// WithTimestamp turn on Timestamp output on the log
+func (l *Logger) WithTimestamp() *Logger {
+	return l.update(func(c *Config) {
+		c.Timestamp = true
+	})
+}
+
+// WithoutTimestamp turn off Timestamp output on the log
+func (l *Logger) WithoutTimestamp() *Logger {
+	return l.update(func(c *Config) {
+		c.Timestamp = false
+	})
+}
+
+// WithDynamicPrefix set a function returning the logger prefix on every write,
+// e.g. to include a correlation ID. It is called under the write lock so it
+// need not be goroutine safe, but it must not use the logger itself.
+func (l *Logger) WithDynamicPrefix(fn func() string) *Logger {
+	return l.update(func(c *Config) {
+		c.PrefixFunc = fn
+	})
+}
+
+// WithHostname cache the machine hostname and add it to every log line
+func (l *Logger) WithHostname() *Logger {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "<unknown host>"
+	}
+	return l.update(func(c *Config) {
+		c.Hostname = hostname
+	})
+}
+
+// WithoutHostname turn off hostname output on the log
+func (l *Logger) WithoutHostname() *Logger {
+	return l.update(func(c *Config) {
+		c.Hostname = ""
+	})
+}
+
+// WithPID cache the process ID and add it to every log line
+func (l *Logger) WithPID() *Logger {
+	pid := os.Getpid()
+	return l.update(func(c *Config) {
+		c.PID = pid
+	})
+}
+
+// WithoutPID turn off process ID output on the log
+func (l *Logger) WithoutPID() *Logger {
+	return l.update(func(c *Config) {
+		c.PID = 0
+	})
+}
+
+// WithSequenceID add the seq=<n> counter to every log line, so the lines
+// written within the same time stamp can still be ordered
+func (l *Logger) WithSequenceID() *Logger {
+	return l.update(func(c *Config) {
+		c.SequenceID = true
+	})
+}
+
+// WithoutSequenceID turn off the sequence counter output on the log
+func (l *Logger) WithoutSequenceID() *Logger {
+	return l.update(func(c *Config) {
+		c.SequenceID = false
+	})
+}
+
+// Quiet turn off all log output
+func (l *Logger) Quiet() *Logger {
+	return l.update(func(c *Config) {
+		c.Quiet = true
+	})
 }
 
 // NoQuiet turn on all log output
 func (l *Logger) NoQuiet() *Logger {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.config.Quiet = false
-	return l
+	return l.update(func(c *Config) {
+		c.Quiet = false
+	})
 }
 
 // IsQuiet check for Quiet state
 func (l *Logger) IsQuiet() bool {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.config.Quiet
+	return l.config.Load().Quiet
 }
 
-// Output print the actual value
+// Output print the actual value with the given prefix, it is the stable
+// extension point to write a custom wrapper or level. The depth is the number
+// of stack frames between the caller to report and Output, one for a direct
+// call and one more for every wrapper function.
 func (l *Logger) Output(depth int, prefix Prefix, data string) error {
+	return l.output(depth+1, prefix, []byte(data), nil, nil)
+}
+
+// OutputBytes print the actual value from byte slice, it avoid the string
+// conversion for callers already holding the message as bytes. The data is
+// not retained after the call returns.
+func (l *Logger) OutputBytes(depth int, prefix Prefix, data []byte) error {
+	return l.output(depth+1, prefix, data, nil, nil)
+}
+
+// Write log p as an info message with the usual formatting, so the logger
+// can be used as io.Writer, e.g. as the output of the standard log package.
+// It returns len(p) when the line is written or suppressed.
+func (l *Logger) Write(p []byte) (int, error) {
+	if err := l.output(1, l.prefix(LevelInfo), p, nil, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// OutputTo print the actual value to the logger output and also to w. The
+// line written to w is colored only when w is a terminal, regardless of the
+// logger color setting.
+func (l *Logger) OutputTo(w io.Writer, depth int, prefix Prefix, data string) error {
+	return l.output(depth+1, prefix, []byte(data), nil, w)
+}
+
+// output is the shared implementation of the Output variants, the fields are
+// added to the logger fields for this line only, and the line is also
+// written to extra when it is not nil
+func (l *Logger) output(depth int, prefix Prefix, data []byte, fields []Field, extra io.Writer) error {
+	// Take a configuration snapshot used for the whole line
+	c := l.config.Load()
 	// Check if Quiet is requested, and try to return no error and be Quiet
-	if l.IsQuiet() {
+	if c.Quiet {
+		return nil
+	}
+	// Skip the message if its level is below the configured level
+	if !c.mayEnable(prefix.Level) {
+		return nil
+	}
+	// Skip the message if the conditional predicate is not satisfied
+	if c.predicate != nil && !c.predicate() {
 		return nil
 	}
 	// Get current time
-	now := time.Now()
+	now := c.now()
 	// Temporary storage for file and line tracing
 	var file string
 	var line int
 	var fn string
-	// Check if the specified prefix needs to be included with file logging
-	if prefix.File {
+	// Apply the caller override of the logger
+	switch c.Caller {
+	case CallerOn:
+		prefix.File = true
+	case CallerOff:
+		prefix.File = false
+	default:
+		// Hide the caller info on a narrow terminal
+		if c.narrow {
+			prefix.File = false
+		}
+	}
+	// Check if the specified prefix needs to be included with file logging,
+	// the caller is also needed to match the package level rules
+	if prefix.File || len(c.packageLevels) > 0 {
 		var ok bool
 		var pc uintptr
 
@@ -216,145 +702,367 @@ func (l *Logger) Output(depth int, prefix Prefix, data string) error {
 			fn = "<unknown function>"
 			line = 0
 		} else {
-			file = filepath.Base(file)
-			fn = runtime.FuncForPC(pc).Name()
+			file = c.callerFile(file)
+			fn = funcName(pc)
 		}
 	}
-	// Acquire exclusive access to the shared buffer
+	// Skip the message if its level is below the caller package level
+	if !c.isEnabledFor(prefix.Level, fn) {
+		return nil
+	}
+	// Skip the message rejected by the caller filter
+	if prefix.File && c.callerFilter != nil && !c.callerFilter(file, fn) {
+		return nil
+	}
+	// Report the emitted line to the metrics observer and entry callback and
+	// write failure to the error handler after the lock is released
+	var err error
+	var r record
+	emitted := false
+	defer func() {
+		if emitted && c.MetricsObserver != nil {
+			c.MetricsObserver(prefix.Level)
+		}
+		if emitted && (c.entryCallback != nil || c.entries != nil) && len(c.middlewares) == 0 {
+			c.emitEntry(&r, now)
+		}
+		if err != nil && c.ErrorHandler != nil {
+			c.ErrorHandler(err)
+		}
+	}()
+	// Mask the sensitive parts of the message
+	if len(c.redactors) > 0 {
+		data = c.redact(data)
+	}
+	// Drop the message rejected by a filter
+	if len(c.filters) > 0 && !c.allow(prefix.Level, data) {
+		return nil
+	}
+	r = record{
+		now:    now,
+		prefix: prefix,
+		file:   file,
+		line:   line,
+		fn:     fn,
+		data:   data,
+	}
+	// Add the fields of the call to the logger ones
+	if len(fields) > 0 {
+		fields = mergeFields(c.fields, fields)
+	} else {
+		fields = c.fields
+	}
+	r.fields = fields
+	// Capture the caller stack for error and fatal message if requested
+	var stack []stackFrame
+	if c.AutoStackOnError && prefix.Level >= LevelError {
+		stack = captureStack(depth+1, c.StackDepth)
+		r.stack = stack
+	}
+	// Pass the line through the middlewares, the last one write it
+	if len(c.middlewares) > 0 {
+		var werr error
+		emitted, werr = l.handle(c, &r, now, prefix, stack, fields, extra)
+		if emitted {
+			err = werr
+		}
+		return werr
+	}
+	werr := l.writeRecord(c, &r, extra, &emitted)
+	if emitted {
+		err = werr
+	}
+	return werr
+}
+
+// writeRecord format the record and write it to the output and extra, emitted
+// is set unless the logger is closed or the line is a duplicate
+func (l *Logger) writeRecord(c *Config, r *record, extra io.Writer, emitted *bool) error {
+	// Take a line buffer from the pool, it is returned after the write
+	buf := getBuffer()
+	defer putBuffer(buf)
+	// Format before taking the lock when the line does not depend on the
+	// previous ones, so the concurrent callers only serialize on the write
+	stateless := c.stateless()
+	if stateless {
+		r.name = c.Prefix
+		l.format(buf, c, r)
+	}
+	// Acquire exclusive access to the output
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	// Reset buffer so it start from the begining
-	l.buf.Reset()
-	// Write prefix to the buffer
-	if l.config.Color {
-		l.buf.Off()
-		l.buf.Append([]byte("[" + l.config.Prefix + "]"))
-		l.buf.Append(prefix.Color)
+	if l.closed {
+		return ErrClosed
+	}
+	var err error
+	if stateless {
+		*emitted = true
+		err = l.flushBuffer(buf, c, r)
 	} else {
-		l.buf.Append([]byte("[" + l.config.Prefix + "]"))
-		l.buf.Append(prefix.Plain)
-	}
-	// Check if the log require timestamping
-	if l.config.Timestamp {
-		// Print Timestamp Color if Color enabled
-		if l.config.Color {
-			l.buf.Blue()
-		}
-		// Print date and time
-		year, month, day := now.Date()
-		l.buf.AppendInt(year, 4)
-		l.buf.AppendByte('/')
-		l.buf.AppendInt(int(month), 2)
-		l.buf.AppendByte('/')
-		l.buf.AppendInt(day, 2)
-		l.buf.AppendByte(' ')
-		hour, min, sec := now.Clock()
-		l.buf.AppendInt(hour, 2)
-		l.buf.AppendByte(':')
-		l.buf.AppendInt(min, 2)
-		l.buf.AppendByte(':')
-		l.buf.AppendInt(sec, 2)
-		l.buf.AppendByte(' ')
-		// Print reset Color if Color enabled
-		if l.config.Color {
-			l.buf.Off()
+		// Collapse repeated message if deduplication is enabled
+		if c.DedupWindow > 0 && l.dedup(c, r) {
+			return nil
 		}
+		*emitted = true
+		err = l.write(buf, c, r)
 	}
-	// Add caller filename and line if enabled
-	if prefix.File {
-		// Print Color start if enabled
-		if l.config.Color {
-			l.buf.Orange()
-		}
-		// Print filename and line
-		l.buf.Append([]byte(fn))
-		l.buf.AppendByte(':')
-		l.buf.Append([]byte(file))
-		l.buf.AppendByte(':')
-		l.buf.AppendInt(line, 0)
-		l.buf.AppendByte(' ')
-		// Print Color stop
-		if l.config.Color {
-			l.buf.Off()
+	if extra != nil {
+		if xerr := l.writeExtra(buf, c, extra, r); err == nil {
+			err = xerr
 		}
 	}
-	// Print the actual string data from caller
-	l.buf.Append([]byte(data))
-	if len(data) == 0 || data[len(data)-1] != '\n' {
-		l.buf.AppendByte('\n')
+	return err
+}
+
+// funcName returns the name of the function containing pc, FuncForPC returns
+// nil when pc is not known to the runtime
+func funcName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc); f != nil {
+		return f.Name()
+	}
+	return "<unknown function>"
+}
+
+// writeExtra write the record to an additional writer, reformatting it when
+// the writer coloring differ from the logger, caller must hold the write lock
+func (l *Logger) writeExtra(buf *colorful.ColorBuffer, c *Config, w io.Writer, r *record) error {
+	if color := isTerminal(w); color != c.Color {
+		extra := *c
+		extra.Color = color
+		l.format(buf, &extra, r)
+	}
+	_, err := w.Write(buf.Buffer)
+	return err
+}
+
+// write format the record into the buffer and flush it to the output, caller
+// must hold the write lock
+func (l *Logger) write(buf *colorful.ColorBuffer, c *Config, r *record) error {
+	// Resolve the logger prefix once, it is reused by writeExtra
+	r.name = c.Prefix
+	if c.PrefixFunc != nil {
+		r.name = c.PrefixFunc()
+	}
+	// Count the line under the write lock so the numbers follow the output
+	if c.SequenceID {
+		r.seq = l.seq.Add(1)
+	}
+	// Chain the line to the previous one written to the same output
+	var chain *[sha256.Size]byte
+	if c.HashChain {
+		chain = l.chainOf(c.levelOutput(r.prefix.Level))
+		r.prev = *chain
 	}
-	// Flush buffer to output
-	_, err := l.config.Out.Write(l.buf.Buffer)
+	l.format(buf, c, r)
+	if chain != nil {
+		*chain = sha256.Sum256(buf.Buffer)
+	}
+	return l.flushBuffer(buf, c, r)
+}
+
+// stateless check whether a line can be formatted without the write lock,
+// which is when it does not depend on the lines written before it and the
+// prefix function, documented as called under the lock, is not set
+func (c *Config) stateless() bool {
+	return c.PrefixFunc == nil && !c.SequenceID && !c.HashChain && c.DedupWindow == 0 && !c.AlignedFields
+}
+
+// flushBuffer write the formatted record to the output of its level, caller
+// must hold the write lock
+func (l *Logger) flushBuffer(buf *colorful.ColorBuffer, c *Config, r *record) error {
+	out := c.levelOutput(r.prefix.Level)
+	// Pass the parts of the line to the journal, the concrete type keep the
+	// record on the stack
+	if jw, ok := out.(*JournalWriter); ok {
+		return jw.writeRecord(c, r)
+	}
+	// Flush buffer to output, passing the level to the writers needing it
+	if lw, ok := out.(levelWriter); ok {
+		_, err := lw.WriteLevel(r.prefix.Level, buf.Buffer)
+		return err
+	}
+	_, err := out.Write(buf.Buffer)
 	return err
 }
 
+// levelOutput returns the output of the level, the level writer if any
+func (c *Config) levelOutput(level Level) FdWriter {
+	if level.valid() && c.writers[level.index()] != nil {
+		return c.writers[level.index()]
+	}
+	return c.Out
+}
+
+// format write the record into the reset buffer, caller must hold the write
+// lock unless the config is stateless, where the line does not depend on the
+// previous ones and is formatted before taking the lock
+func (l *Logger) format(buf *colorful.ColorBuffer, c *Config, r *record) {
+	// Reset buffer so it start from the begining
+	buf.Reset()
+	// Format the log line into the buffer
+	switch c.Format {
+	case FormatJSON:
+		l.formatJSON(buf, c, r)
+	case FormatGELF:
+		l.formatGELF(buf, c, r)
+	default:
+		l.formatText(buf, c, r)
+	}
+	// Drop the line terminator if the framing is handled elsewhere
+	if c.NoTrailingNewline {
+		buf.Buffer = bytes.TrimSuffix(buf.Buffer, []byte("\n"))
+	}
+}
+
+// isTerminal check whether w is a terminal character device
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// truncate cut the message to max bytes without splitting a UTF-8 rune and
+// append a marker with the number of dropped bytes
+func truncate(data []byte, max int) []byte {
+	msg := bytes.TrimSuffix(data, []byte("\n"))
+	if len(msg) <= max {
+		return data
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	dropped := len(msg) - cut
+	// Limit the capacity so the marker never overwrite the caller data
+	msg = append(msg[:cut:cut], "...[truncated "...)
+	msg = strconv.AppendInt(msg, int64(dropped), 10)
+	return append(msg, " bytes]"...)
+}
+
+// WithExitFunc replace os.Exit called by Fatal after the message is written,
+// e.g. to run the cleanup or to stub it in tests
+func (l *Logger) WithExitFunc(fn func(code int)) *Logger {
+	return l.update(func(c *Config) {
+		c.ExitFunc = fn
+	})
+}
+
+// exit flush the output and terminate the program with the exit function
+func (l *Logger) exit(code int) {
+	l.flush()
+	if fn := l.config.Load().ExitFunc; fn != nil {
+		fn(code)
+		return
+	}
+	os.Exit(code)
+}
+
 // Fatal print fatal message to output and quit the application with status 1
 func (l *Logger) Fatal(v ...interface{}) {
-	l.Output(1, FatalPrefix, fmt.Sprintln(v...))
-	os.Exit(1)
+	l.outputln(1, l.prefix(LevelFatal), v)
+	l.exit(1)
 }
 
 // Fatalf print formatted fatal message to output and quit the application
 // with status 1
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	l.Output(1, FatalPrefix, fmt.Sprintf(format, v...))
-	os.Exit(1)
+	l.Output(1, l.prefix(LevelFatal), fmt.Sprintf(format, v...))
+	l.exit(1)
+}
+
+// Panic print fatal message to output, flush the output and panic with the
+// message, the program does not exit through ExitFunc
+func (l *Logger) Panic(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	l.Output(1, l.prefix(LevelFatal), msg)
+	l.flush()
+	panic(msg)
+}
+
+// Panicf print formatted fatal message to output, flush the output and panic
+// with the message
+func (l *Logger) Panicf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.Output(1, l.prefix(LevelFatal), msg)
+	l.flush()
+	panic(msg)
+}
+
+// FatalErr print fatal message to output and returns it as error instead of
+// quitting the application, ExitFunc is not called
+func (l *Logger) FatalErr(v ...interface{}) error {
+	l.outputln(1, l.prefix(LevelFatal), v)
+	return errors.New(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// FatalfErr print formatted fatal message to output and returns it as error
+// instead of quitting the application, ExitFunc is not called
+func (l *Logger) FatalfErr(format string, v ...interface{}) error {
+	msg := fmt.Sprintf(format, v...)
+	l.Output(1, l.prefix(LevelFatal), msg)
+	return errors.New(msg)
 }
 
 // Error print error message to output
 func (l *Logger) Error(v ...interface{}) {
-	l.Output(1, ErrorPrefix, fmt.Sprintln(v...))
+	l.outputln(1, l.prefix(LevelError), v)
 }
 
 // Errorf print formatted error message to output
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	l.Output(1, ErrorPrefix, fmt.Sprintf(format, v...))
+	l.Output(1, l.prefix(LevelError), fmt.Sprintf(format, v...))
 }
 
 // Warn print warning message to output
 func (l *Logger) Warn(v ...interface{}) {
-	l.Output(1, WarnPrefix, fmt.Sprintln(v...))
+	l.outputln(1, l.prefix(LevelWarn), v)
 }
 
 // Warnf print formatted warning message to output
 func (l *Logger) Warnf(format string, v ...interface{}) {
-	l.Output(1, WarnPrefix, fmt.Sprintf(format, v...))
+	l.Output(1, l.prefix(LevelWarn), fmt.Sprintf(format, v...))
 }
 
 // Info print informational message to output
 func (l *Logger) Info(v ...interface{}) {
-	l.Output(1, InfoPrefix, fmt.Sprintln(v...))
+	l.outputln(1, l.prefix(LevelInfo), v)
 }
 
 // Infof print formatted informational message to output
 func (l *Logger) Infof(format string, v ...interface{}) {
-	l.Output(1, InfoPrefix, fmt.Sprintf(format, v...))
+	l.Output(1, l.prefix(LevelInfo), fmt.Sprintf(format, v...))
 }
 
 // Debug print Debug message to output if Debug output enabled
 func (l *Logger) Debug(v ...interface{}) {
-	if l.IsDebug() {
-		l.Output(1, DebugPrefix, fmt.Sprintln(v...))
+	if l.mayEnable(LevelDebug) {
+		l.outputln(1, l.prefix(LevelDebug), v)
 	}
 }
 
 // Debugf print formatted Debug message to output if Debug output enabled
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if l.IsDebug() {
-		l.Output(1, DebugPrefix, fmt.Sprintf(format, v...))
+	if l.mayEnable(LevelDebug) {
+		l.Output(1, l.prefix(LevelDebug), fmt.Sprintf(format, v...))
 	}
 }
 
 // Trace print trace message to output if Debug output enabled
 func (l *Logger) Trace(v ...interface{}) {
-	if l.IsDebug() {
-		l.Output(1, TracePrefix, fmt.Sprintln(v...))
+	if l.mayEnable(LevelTrace) {
+		l.outputln(1, l.prefix(LevelTrace), v)
 	}
 }
 
 // Tracef print formatted trace message to output if Debug output enabled
 func (l *Logger) Tracef(format string, v ...interface{}) {
-	if l.IsDebug() {
-		l.Output(1, TracePrefix, fmt.Sprintf(format, v...))
+	if l.mayEnable(LevelTrace) {
+		l.Output(1, l.prefix(LevelTrace), fmt.Sprintf(format, v...))
 	}
 }
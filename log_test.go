@@ -0,0 +1,2854 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/csturiale/go-log/colorful"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+func TestLevel(t *testing.T) {
+	Convey("Given logger with default level", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("It should suppress debug and trace output", func() {
+			l.Debug("debug")
+			l.Trace("trace")
+			So(out.Len(), ShouldEqual, 0)
+		})
+
+		Convey("When level set to error", func() {
+			l.WithLevel(LevelError)
+			l.Warn("warn")
+			l.Error("error")
+
+			Convey("It should only write the error message", func() {
+				So(out.String(), ShouldNotContainSubstring, "warn")
+				So(out.String(), ShouldContainSubstring, "error")
+			})
+		})
+
+		Convey("When level shifted past the extremes", func() {
+			l.shiftLevel(-10)
+			So(l.config.Load().Level, ShouldEqual, LevelTrace)
+			l.shiftLevel(10)
+			So(l.config.Load().Level, ShouldEqual, LevelFatal)
+		})
+
+		Convey("When debug enabled", func() {
+			l.WithDebug()
+
+			Convey("It should enable trace level", func() {
+				So(l.IsEnabled(LevelTrace), ShouldBeTrue)
+			})
+
+			Convey("It should go back to info level when disabled", func() {
+				l.WithoutDebug()
+				So(l.IsEnabled(LevelDebug), ShouldBeFalse)
+				So(l.IsEnabled(LevelInfo), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestBuffered(t *testing.T) {
+	Convey("Given buffered entry from a logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		entry, flush := l.Buffered()
+		entry.Info("first")
+		entry.Warn("second")
+
+		Convey("It should not write before flushed", func() {
+			So(out.Len(), ShouldEqual, 0)
+		})
+
+		Convey("When flushed", func() {
+			flush()
+
+			Convey("It should write the lines in order", func() {
+				So(out.String(), ShouldEqual, "[][INFO]  first\n[][WARN]  second\n")
+			})
+
+			Convey("It should not write the lines again", func() {
+				flush()
+				So(out.String(), ShouldEqual, "[][INFO]  first\n[][WARN]  second\n")
+			})
+		})
+	})
+
+	Convey("Given buffered entry from a logger with a tee output", t, func() {
+		var pri, sec testWriter
+		l := newLogger(Config{Out: NewTeeWriter(&pri, &sec, LevelWarn)})
+		entry, flush := l.Buffered()
+		entry.Info("first")
+		entry.Warn("second")
+		flush()
+
+		Convey("It should pass the level of the lines to the tee", func() {
+			So(pri.String(), ShouldEqual, "[][INFO]  first\n[][WARN]  second\n")
+			So(sec.String(), ShouldEqual, "[][WARN]  second\n")
+		})
+	})
+
+	Convey("Given buffered entry from a logger with a warn writer", t, func() {
+		var out, warns testWriter
+		l := newLogger(Config{Out: &out}).WithLevelWriter(LevelWarn, &warns)
+		entry, flush := l.Buffered()
+		entry.Info("first")
+		entry.Warn("second")
+
+		Convey("It should buffer the lines of every level", func() {
+			So(out.Len(), ShouldEqual, 0)
+			So(warns.Len(), ShouldEqual, 0)
+		})
+
+		Convey("It should flush the lines to the writer of their level", func() {
+			flush()
+			So(out.String(), ShouldEqual, "[][INFO]  first\n")
+			So(warns.String(), ShouldEqual, "[][WARN]  second\n")
+		})
+	})
+}
+
+func TestHostnameAndPID(t *testing.T) {
+	Convey("Given logger with hostname and process ID", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithHostname().WithPID()
+		l.Info("hello")
+
+		Convey("It should include both as static fields", func() {
+			So(out.String(), ShouldContainSubstring, "host="+l.config.Load().Hostname+" ")
+			So(out.String(), ShouldContainSubstring, fmt.Sprintf("pid=%d ", os.Getpid()))
+		})
+	})
+}
+
+func TestSequenceID(t *testing.T) {
+	Convey("Given logger with sequence ID", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithSequenceID()
+
+		Convey("It should count the lines from 1", func() {
+			l.Info("first")
+			l.Warn("second")
+			So(out.String(), ShouldEqual, "[][INFO]  seq=1 first\n[][WARN]  seq=2 second\n")
+		})
+
+		Convey("It should write the counter as number in JSON format", func() {
+			l.WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","seq":1,"msg":"hello"}`+"\n")
+		})
+
+		Convey("It should wrap around after the largest value", func() {
+			l.seq.Store(math.MaxUint64 - 1)
+			l.Info("last")
+			l.Info("wrapped")
+			So(out.String(), ShouldEqual, "[][INFO]  seq=18446744073709551615 last\n[][INFO]  seq=0 wrapped\n")
+		})
+
+		Convey("It should not count the lines below the level", func() {
+			l.Debug("skipped")
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  seq=1 hello\n")
+		})
+
+		Convey("It should share the counter with the child loggers", func() {
+			l.Info("first")
+			l.WithField("k", 1).Info("second")
+			l.Infow("third", "k", 1)
+			So(out.String(), ShouldEqual, "[][INFO]  seq=1 first\n[][INFO]  seq=2 second k=1\n[][INFO]  seq=3 third k=1\n")
+		})
+
+		Convey("It should start a new counter for the clones", func() {
+			l.Info("first")
+			l.Clone().Info("second")
+			So(out.String(), ShouldEqual, "[][INFO]  seq=1 first\n[][INFO]  seq=1 second\n")
+		})
+	})
+}
+
+func TestAudit(t *testing.T) {
+	Convey("Given audit logger used concurrently", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithAudit()
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					l.Info("event")
+				}
+			}()
+		}
+		wg.Wait()
+		lines := strings.SplitAfter(out.String(), "\n")
+		lines = lines[:len(lines)-1]
+		pattern := regexp.MustCompile(`^\[\]\[INFO\]  seq=(\d+) prev=([0-9a-f]{64}) event\n$`)
+
+		Convey("It should number the lines without gap or duplicate", func() {
+			So(lines, ShouldHaveLength, 200)
+			for i, line := range lines {
+				m := pattern.FindStringSubmatch(line)
+				So(m, ShouldHaveLength, 3)
+				So(m[1], ShouldEqual, fmt.Sprint(i+1))
+			}
+		})
+
+		Convey("It should chain every line to the hash of the previous one", func() {
+			prev := strings.Repeat("0", 64)
+			for _, line := range lines {
+				So(pattern.FindStringSubmatch(line)[2], ShouldEqual, prev)
+				sum := sha256.Sum256([]byte(line))
+				prev = hex.EncodeToString(sum[:])
+			}
+		})
+	})
+
+	Convey("Given logger with hash chain in JSON format", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFormat(FormatJSON).WithHashChain()
+
+		Convey("It should write the previous hash as string", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","prev":"`+strings.Repeat("0", 64)+`","msg":"hello"}`+"\n")
+		})
+	})
+
+	Convey("Given logger with hash chain and a level writer", t, func() {
+		var out, errs testWriter
+		l := newLogger(Config{Out: &out}).WithHashChain().WithLevelWriter(LevelError, &errs)
+		pattern := regexp.MustCompile(`prev=([0-9a-f]{64}) `)
+
+		Convey("It should keep a chain for every output shared with the child loggers", func() {
+			l.Info("first")
+			first := out.String()
+			l.Error("failed")
+			l.Named("child").Info("second")
+			zero := strings.Repeat("0", 64)
+			sum := sha256.Sum256([]byte(first))
+			So(pattern.FindStringSubmatch(first)[1], ShouldEqual, zero)
+			So(pattern.FindStringSubmatch(errs.String())[1], ShouldEqual, zero)
+			So(pattern.FindStringSubmatch(out.String()[len(first):])[1], ShouldEqual, hex.EncodeToString(sum[:]))
+		})
+	})
+}
+
+func TestClone(t *testing.T) {
+	Convey("Given logger and its clone", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app"})
+		c := l.Clone()
+
+		Convey("When the clone configuration changed", func() {
+			c.WithDebug().WithTimestamp()
+
+			Convey("It should not affect the original logger", func() {
+				So(l.IsDebug(), ShouldBeFalse)
+				So(l.config.Load().Timestamp, ShouldBeFalse)
+			})
+
+			Convey("It should share the same output", func() {
+				c.Debug("from clone")
+				So(out.String(), ShouldContainSubstring, "from clone")
+			})
+		})
+
+		Convey("It should have its own write lock", func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			So(c.mu.TryLock(), ShouldBeTrue)
+			c.mu.Unlock()
+		})
+	})
+}
+
+// failWriter always fail on write
+type failWriter struct {
+	testWriter
+}
+
+// Write returns closed pipe error
+func (w *failWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestErrorHandler(t *testing.T) {
+	Convey("Given logger with failing output and error handler", t, func() {
+		var reported error
+		l := newLogger(Config{
+			Out:          &failWriter{},
+			ErrorHandler: func(err error) { reported = err },
+		})
+
+		Convey("When logging a message", func() {
+			l.Info("hello")
+
+			Convey("It should report the write error", func() {
+				So(reported, ShouldEqual, io.ErrClosedPipe)
+			})
+		})
+	})
+}
+
+func TestMetricsObserver(t *testing.T) {
+	Convey("Given logger with metrics observer", t, func() {
+		counts := map[Level]int{}
+		l := newLogger(Config{
+			Out:             &failWriter{},
+			MetricsObserver: func(level Level) { counts[level]++ },
+		})
+
+		Convey("When logging at several levels", func() {
+			l.Info("hello")
+			l.Info("hello")
+			l.Error("failed")
+			l.Debug("hidden")
+
+			Convey("It should count the emitted lines even if the write fails", func() {
+				So(counts, ShouldResemble, map[Level]int{LevelInfo: 2, LevelError: 1})
+			})
+		})
+	})
+}
+
+func TestCallerForLevel(t *testing.T) {
+	Convey("Given two loggers", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		other := newLogger(Config{Out: &out})
+
+		Convey("When caller enabled for all levels on the first logger", func() {
+			l.WithCallerForAll()
+
+			Convey("It should print the caller on info level", func() {
+				l.Info("hello")
+				So(out.String(), ShouldContainSubstring, "log_test.go:")
+			})
+
+			Convey("It should not affect the other logger", func() {
+				other.Info("hello")
+				So(out.String(), ShouldNotContainSubstring, "log_test.go:")
+			})
+		})
+
+		Convey("When caller disabled for error level", func() {
+			l.WithCallerForLevel(LevelError, false)
+			l.Error("hello")
+
+			Convey("It should not print the caller", func() {
+				So(out.String(), ShouldNotContainSubstring, "log_test.go:")
+			})
+		})
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	Convey("Given message longer than the limit", t, func() {
+		Convey("It should cut the message and count dropped bytes", func() {
+			So(string(truncate([]byte("hello world\n"), 5)), ShouldEqual, "hello...[truncated 6 bytes]")
+		})
+
+		Convey("It should not split a multi byte rune", func() {
+			So(string(truncate([]byte("héllo"), 2)), ShouldEqual, "h...[truncated 5 bytes]")
+		})
+	})
+
+	Convey("Given message within the limit", t, func() {
+		Convey("It should keep the message intact", func() {
+			So(string(truncate([]byte("hello\n"), 5)), ShouldEqual, "hello\n")
+		})
+	})
+}
+
+func TestGoroutineID(t *testing.T) {
+	Convey("Given logger with goroutine ID", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithGoroutineID()
+
+		Convey("It should print the ID of the calling goroutine", func() {
+			l.Info("hello")
+			So(goroutineID(), ShouldBeGreaterThan, 0)
+			So(out.String(), ShouldContainSubstring, fmt.Sprintf("gid=%d ", goroutineID()))
+		})
+	})
+}
+
+func TestWithError(t *testing.T) {
+	Convey("Given logger and a wrapped error", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		err := fmt.Errorf("query failed: %w", io.EOF)
+
+		Convey("When logged as text", func() {
+			l.WithError(err).Warn("operation failed")
+
+			Convey("It should append the error and its chain as fields", func() {
+				So(out.String(), ShouldEqual, "[][WARN]  operation failed error=\"query failed: EOF\" error_chain=[\"EOF\"]\n")
+			})
+
+			Convey("It should not add the field to the parent logger", func() {
+				out.Reset()
+				l.Warn("operation failed")
+				So(out.String(), ShouldEqual, "[][WARN]  operation failed\n")
+			})
+		})
+
+		Convey("When logged as JSON", func() {
+			l.WithFormat(FormatJSON).WithError(err).Warn("operation failed")
+
+			Convey("It should encode the error as JSON members", func() {
+				So(out.String(), ShouldEqual, `{"level":"WARN","msg":"operation failed","error":"query failed: EOF","error_chain":["EOF"]}`+"\n")
+			})
+		})
+	})
+
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("It should follow the joined errors in the chain", func() {
+			l.WithError(errors.Join(io.EOF, fmt.Errorf("read: %w", io.ErrUnexpectedEOF))).Warn("failed")
+			So(out.String(), ShouldContainSubstring, `error_chain=["EOF","read: unexpected EOF","unexpected EOF"]`)
+		})
+
+		Convey("It should attach the stack of a wrapped error", func() {
+			l.WithError(fmt.Errorf("query failed: %w", &stackError{})).Warn("failed")
+			So(out.String(), ShouldContainSubstring, "stack=main.go:1")
+		})
+
+		Convey("It should skip the stack of a typed nil error", func() {
+			So(func() { l.WithError((*stackError)(nil)).Warn("failed") }, ShouldNotPanic)
+			So(func() { l.WithError(fmt.Errorf("query failed: %w", (*stackError)(nil))).Warn("failed") }, ShouldNotPanic)
+			So(out.String(), ShouldNotContainSubstring, "stack=")
+		})
+	})
+}
+
+// stackError provide a stack like the github.com/pkg/errors errors
+type stackError struct {
+	frame string
+}
+
+// Error returns the error message, even for a nil error
+func (e *stackError) Error() string {
+	return "stack error"
+}
+
+// StackTrace returns the stack, it panics for a nil error
+func (e *stackError) StackTrace() string {
+	return e.frame + "main.go:1"
+}
+
+func TestDedup(t *testing.T) {
+	Convey("Given logger with deduplication", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithDedup(time.Hour)
+
+		Convey("When the same message logged repeatedly then another one", func() {
+			for i := 0; i < 3; i++ {
+				l.Info("retrying")
+			}
+			l.Info("done")
+
+			Convey("It should collapse the repeated lines into a summary", func() {
+				So(out.String(), ShouldEqual, "[][INFO]  retrying\n[][INFO]  last message repeated 2 times\n[][INFO]  done\n")
+			})
+		})
+
+		Convey("When the window elapsed", func() {
+			l.WithDedup(10 * time.Millisecond)
+			l.Info("retrying")
+			l.Info("retrying")
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("It should write the summary", func() {
+				l.mu.RLock()
+				defer l.mu.RUnlock()
+				So(out.String(), ShouldEqual, "[][INFO]  retrying\n[][INFO]  last message repeated 1 times\n")
+			})
+		})
+	})
+}
+
+func TestFieldColumn(t *testing.T) {
+	Convey("Given colored logger with aligned fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Color: true, FieldColumn: 30})
+		child := l.withFields(Field{Key: "id", Value: 1})
+
+		Convey("When messages of different length logged", func() {
+			child.Info("short")
+			child.Warn("a longer message")
+
+			Convey("It should start the fields at the same visible column", func() {
+				lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+				So(lines, ShouldHaveLength, 2)
+				for _, line := range lines {
+					So(visibleWidth([]byte(line[:strings.Index(line, "id=")])), ShouldEqual, 30)
+				}
+			})
+		})
+	})
+}
+
+func TestAlignedFields(t *testing.T) {
+	Convey("Given logger with tabwriter aligned fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithAlignedFields()
+
+		Convey("When fields of different width logged", func() {
+			l.withFields(Field{Key: "user", Value: "barbara"}, Field{Key: "id", Value: 1}).Info("a")
+			l.withFields(Field{Key: "user", Value: "al"}, Field{Key: "id", Value: 2}).Info("a")
+
+			Convey("It should pad the second line columns to the widest cell", func() {
+				lines := strings.Split(out.String(), "\n")
+				So(lines[0], ShouldEqual, "[][INFO]  a user=barbara id=1")
+				So(lines[1], ShouldEqual, "[][INFO]  a user=al      id=2")
+			})
+		})
+
+		Convey("When a colored line also written to a plain extra writer", func() {
+			var extra testWriter
+			l.WithColor().withFields(Field{Key: "user", Value: "al"}).OutputTo(&extra, 1, l.prefix(LevelInfo), "a")
+
+			Convey("It should align the fields once", func() {
+				So(l.align.rows, ShouldHaveLength, 1)
+				So(extra.String(), ShouldEqual, "[][INFO]  a user=al\n")
+			})
+		})
+	})
+}
+
+func TestAutoStack(t *testing.T) {
+	Convey("Given JSON logger with automatic stack on error", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Format: FormatJSON}).WithAutoStack(2)
+
+		Convey("When error logged", func() {
+			l.Error("boom")
+
+			Convey("It should attach the limited stack starting at the caller", func() {
+				var entry struct {
+					Stack []stackFrame `json:"stack"`
+				}
+				So(json.Unmarshal(out.Bytes(), &entry), ShouldBeNil)
+				So(entry.Stack, ShouldHaveLength, 2)
+				So(entry.Stack[0].Func, ShouldContainSubstring, "TestAutoStack")
+			})
+		})
+
+		Convey("When warning logged", func() {
+			l.Warn("careful")
+
+			Convey("It should not attach the stack", func() {
+				So(out.String(), ShouldNotContainSubstring, "stack")
+			})
+		})
+
+		Convey("It should capture no frame when skipping the whole stack", func() {
+			So(captureStack(1000, 2), ShouldBeNil)
+		})
+	})
+}
+
+func TestIf(t *testing.T) {
+	Convey("Given conditional logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		enabled := false
+		cond := l.If(func() bool { return enabled && !l.IsQuiet() })
+
+		Convey("It should skip the line when predicate is false", func() {
+			cond.Info("hidden")
+			So(out.Len(), ShouldEqual, 0)
+		})
+
+		Convey("It should write the line when predicate is true", func() {
+			enabled = true
+			cond.Info("shown")
+			So(out.String(), ShouldContainSubstring, "shown")
+		})
+	})
+}
+
+func TestOutputBytes(t *testing.T) {
+	Convey("Given logger and message as bytes", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithCallerForAll()
+		data := []byte("from bytes")
+
+		Convey("When written with OutputBytes", func() {
+			l.OutputBytes(0, l.prefix(LevelInfo), data)
+
+			Convey("It should format the line like Output", func() {
+				So(out.String(), ShouldStartWith, "[][INFO]  ")
+				So(out.String(), ShouldContainSubstring, "log_test.go:")
+				So(out.String(), ShouldEndWith, " from bytes\n")
+			})
+		})
+	})
+}
+
+func TestOutputTo(t *testing.T) {
+	Convey("Given colored logger and a side writer", t, func() {
+		var out, side testWriter
+		l := newLogger(Config{Out: &out, Color: true})
+
+		Convey("When a line written with OutputTo", func() {
+			l.OutputTo(&side, 0, l.prefix(LevelInfo), "progress")
+
+			Convey("It should write colored line to the logger output", func() {
+				So(out.String(), ShouldContainSubstring, "\033[")
+			})
+
+			Convey("It should write plain line to the non terminal writer", func() {
+				So(side.String(), ShouldEqual, "[][INFO]  progress\n")
+			})
+		})
+	})
+}
+
+func TestLevelForPackage(t *testing.T) {
+	Convey("Given function names", t, func() {
+		Convey("It should extract the package path", func() {
+			So(packageName("example.com/app/payment.(*Service).Charge"), ShouldEqual, "example.com/app/payment")
+			So(packageName("main.main"), ShouldEqual, "main")
+		})
+	})
+
+	Convey("Given logger with package level rules", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("When this package allowed to log debug", func() {
+			l.SetLevelForPackage("github.com/csturiale", LevelError)
+			l.SetLevelForPackage("github.com/csturiale/go-log", LevelDebug)
+			l.Debug("visible")
+
+			Convey("It should use the longest matching rule", func() {
+				So(out.String(), ShouldContainSubstring, "visible")
+			})
+		})
+
+		Convey("When other package allowed to log debug", func() {
+			l.SetLevelForPackage("github.com/csturiale/go-log/netlog", LevelDebug)
+			l.Debug("hidden")
+
+			Convey("It should use the logger level", func() {
+				So(out.Len(), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestNoTrailingNewline(t *testing.T) {
+	Convey("Given logger without trailing newline", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, NoTrailingNewline: true})
+
+		Convey("It should not terminate the text line", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello")
+		})
+
+		Convey("It should not terminate the JSON line", func() {
+			l.WithFormat(FormatJSON).Infof("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello"}`)
+		})
+	})
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	Convey("Given logger with lifecycle events", t, func() {
+		var out testWriter
+		newLogger(Config{Out: &out, Prefix: "app"}).WithLifecycleEvents()
+
+		Convey("It should log the initialized event with instance identification", func() {
+			So(out.String(), ShouldStartWith, "[app][INFO]  logger initialized host=")
+			So(out.String(), ShouldContainSubstring, fmt.Sprintf(" pid=%d\n", os.Getpid()))
+		})
+	})
+
+	Convey("Given logger with lifecycle events and closable output", t, func() {
+		out := &closeWriter{}
+		l := newLogger(Config{Out: out, Prefix: "app"}).WithLifecycleEvents()
+		out.Reset()
+
+		Convey("It should log the closed event before closing the output", func() {
+			So(l.Close(), ShouldBeNil)
+			So(out.String(), ShouldStartWith, "[app][INFO]  logger closed host=")
+			So(out.closed, ShouldBeTrue)
+		})
+	})
+}
+
+// closeWriter record whether it has been closed
+type closeWriter struct {
+	testWriter
+	closed bool
+}
+
+// Close mark the writer as closed
+func (w *closeWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+// syncWriter record the content written when it is synced
+type syncWriter struct {
+	testWriter
+	synced string
+}
+
+// Sync record the current content
+func (w *syncWriter) Sync() error {
+	w.synced = w.String()
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	Convey("Given logger with write buffer over syncable output", t, func() {
+		out := &syncWriter{}
+		l := newLogger(Config{Out: out}).WithWriteBuffer(4096, 0)
+		l.Info("hello")
+
+		Convey("It should flush the buffer before syncing", func() {
+			So(out.synced, ShouldEqual, "")
+			So(l.Sync(), ShouldBeNil)
+			So(out.synced, ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+
+	Convey("Given logger writing to a file", t, func() {
+		f, err := os.CreateTemp(t.TempDir(), "sync")
+		So(err, ShouldBeNil)
+		defer f.Close()
+		l := newLogger(Config{Out: f})
+		l.Info("hello")
+
+		Convey("It should sync the file", func() {
+			So(l.Sync(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given logger writing to a pipe", t, func() {
+		r, w, err := os.Pipe()
+		So(err, ShouldBeNil)
+		defer r.Close()
+		defer w.Close()
+		l := newLogger(Config{Out: w})
+
+		Convey("It should ignore the pipe which can not be synced", func() {
+			So(l.Sync(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given logger writing to an output without sync", t, func() {
+		l := newLogger(Config{Out: &testWriter{}})
+
+		Convey("It should do nothing", func() {
+			So(l.Sync(), ShouldBeNil)
+		})
+	})
+}
+
+func TestClose(t *testing.T) {
+	Convey("Given logger with closable output", t, func() {
+		out := &closeWriter{}
+		l := newLogger(Config{Out: out})
+
+		Convey("When the logger closed", func() {
+			So(l.Close(), ShouldBeNil)
+
+			Convey("It should close the output", func() {
+				So(out.closed, ShouldBeTrue)
+			})
+
+			Convey("It should refuse further writes", func() {
+				So(l.Output(0, l.prefix(LevelInfo), "late"), ShouldEqual, ErrClosed)
+				So(out.Len(), ShouldEqual, 0)
+			})
+
+			Convey("It should fail to close again", func() {
+				So(l.Close(), ShouldEqual, ErrClosed)
+			})
+		})
+
+		Convey("When a child logger is created before closing", func() {
+			child := l.WithField("k", 1)
+			So(l.Close(), ShouldBeNil)
+
+			Convey("It should refuse the writes of the child logger", func() {
+				So(child.Output(0, child.prefix(LevelInfo), "late"), ShouldEqual, ErrClosed)
+				So(l.WithField("k", 2).Output(0, l.prefix(LevelInfo), "late"), ShouldEqual, ErrClosed)
+				So(out.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("It should close once when closed concurrently", func() {
+			var closed atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if l.Close() == nil {
+						closed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+			So(closed.Load(), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestGELFFormat(t *testing.T) {
+	Convey("Given logger with GELF format", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithGELFFormat("web-1")
+
+		Convey("When a warning with fields logged", func() {
+			l.withFields(Field{Key: "id", Value: 7}, Field{Key: "user", Value: "al"}).Warn("slow\nquery")
+
+			Convey("It should encode GELF members", func() {
+				var msg map[string]interface{}
+				So(json.Unmarshal(out.Bytes(), &msg), ShouldBeNil)
+				So(msg["version"], ShouldEqual, "1.1")
+				So(msg["host"], ShouldEqual, "web-1")
+				So(msg["short_message"], ShouldEqual, "slow")
+				So(msg["full_message"], ShouldEqual, "slow\nquery")
+				So(msg["level"], ShouldEqual, 4)
+				So(msg["_user"], ShouldEqual, "al")
+			})
+
+			Convey("It should not send the reserved id field", func() {
+				So(out.String(), ShouldNotContainSubstring, `"_id`)
+			})
+		})
+
+		Convey("It should not send the fields with invalid key", func() {
+			l.WithFields(Fields{"user name": "al", "req.id-2": 1}).Info("hello")
+			So(out.String(), ShouldNotContainSubstring, "user name")
+			So(out.String(), ShouldContainSubstring, `"_req.id-2":1}`)
+		})
+
+		Convey("It should terminate the messages with a null byte for TCP", func() {
+			l.WithGELFTCP().Info("hello")
+			So(out.String(), ShouldEndWith, "}\x00")
+		})
+
+		Convey("It should write the list and other values as strings", func() {
+			l.withFields(
+				Field{Key: "tags", Value: []string{"a", "b"}},
+				Field{Key: "ok", Value: true},
+				Field{Key: "n", Value: 3},
+			).Info("hello")
+			So(out.String(), ShouldContainSubstring, `"_tags":"a,b","_ok":"true","_n":3}`)
+		})
+
+		Convey("It should write the stack as a string", func() {
+			l.WithAutoStack(1).Error("failed")
+			var msg map[string]interface{}
+			So(json.Unmarshal(out.Bytes(), &msg), ShouldBeNil)
+			stack, ok := msg["_stack"].(string)
+			So(ok, ShouldBeTrue)
+			So(stack, ShouldStartWith, "github.com/csturiale/go-log.TestGELFFormat")
+			So(stack, ShouldContainSubstring, "log_test.go:")
+		})
+	})
+}
+
+func TestConcurrentReconfiguration(t *testing.T) {
+	Convey("Given logger reconfigured while logging", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					l.WithTimestamp().WithFormat(FormatJSON).WithLevel(LevelDebug)
+					l.WithoutTimestamp().WithFormat(FormatText).WithLevel(LevelInfo)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					l.Info("hello")
+					l.Debug("world")
+				}
+			}()
+		}
+		wg.Wait()
+
+		Convey("It should write every info line whole", func() {
+			So(strings.Count(out.String(), "hello"), ShouldEqual, 400)
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+				So(strings.HasSuffix(line, "hello") || strings.HasSuffix(line, "world") ||
+					strings.HasSuffix(line, `"msg":"hello"}`) || strings.HasSuffix(line, `"msg":"world"}`), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestConcurrentOutput(t *testing.T) {
+	Convey("Given logger used by 100 goroutines", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithField("user", "al")
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					l.Infof("worker %d line %d", i, j)
+				}
+			}(i)
+		}
+		wg.Wait()
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		pattern := regexp.MustCompile(`^\[\]\[INFO\]  worker \d+ line \d user=al$`)
+
+		Convey("It should write every line whole", func() {
+			So(lines, ShouldHaveLength, 1000)
+			for _, line := range lines {
+				So(pattern.MatchString(line), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestDynamicPrefix(t *testing.T) {
+	Convey("Given logger with dynamic prefix", t, func() {
+		var out testWriter
+		calls := 0
+		l := newLogger(Config{Out: &out, Prefix: "static"}).WithDynamicPrefix(func() string {
+			calls++
+			return "req-" + fmt.Sprint(calls)
+		})
+
+		Convey("It should call the function on every write", func() {
+			l.Info("one")
+			l.Info("two")
+			So(out.String(), ShouldEqual, "[req-1][INFO]  one\n[req-2][INFO]  two\n")
+		})
+
+		Convey("It should use the dynamic prefix in JSON", func() {
+			l.WithFormat(FormatJSON).Info("one")
+			So(out.String(), ShouldEqual, `{"level":"INFO","prefix":"req-1","msg":"one"}`+"\n")
+		})
+
+		Convey("It should fall back to the static prefix when nil", func() {
+			l.WithDynamicPrefix(nil).Info("one")
+			So(out.String(), ShouldEqual, "[static][INFO]  one\n")
+		})
+	})
+}
+
+func TestOutputAllocation(t *testing.T) {
+	Convey("Given logger without timestamp and caller", t, func() {
+		l := newLogger(Config{Out: discardWriter{}})
+		prefix := l.prefix(LevelInfo)
+
+		Convey("It should write without allocation", func() {
+			allocs := testing.AllocsPerRun(100, func() {
+				l.Output(1, prefix, "hello world")
+			})
+			So(allocs, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestUnknownCaller(t *testing.T) {
+	Convey("Given logger with caller info", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithCallerForAll()
+
+		Convey("It should write placeholders when the depth is beyond the stack", func() {
+			So(l.Output(1<<20, l.prefix(LevelInfo), "hello"), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  <unknown function>:<unknown file>:0 hello\n")
+		})
+
+		Convey("It should not panic on a program counter unknown to the runtime", func() {
+			So(funcName(0), ShouldEqual, "<unknown function>")
+		})
+	})
+}
+
+func TestColorScope(t *testing.T) {
+	Convey("Given colored logger with timestamp and caller", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Color: true, Timestamp: true}).WithCallerForAll()
+
+		Convey("It should color every region by default", func() {
+			l.Info("hello")
+			So(out.String(), ShouldContainSubstring, "\033[0;34m")
+			So(out.String(), ShouldContainSubstring, "\033[0;33m")
+			So(out.String(), ShouldContainSubstring, string(InfoPrefix.Color))
+		})
+
+		Convey("It should color only the level tag in ColorLevelOnly", func() {
+			l.WithColorScope(ColorLevelOnly).Info("hello")
+			line := strings.Replace(out.String(), string(InfoPrefix.Color), "", 1)
+			So(out.String(), ShouldStartWith, "\033[0m[]"+string(InfoPrefix.Color))
+			So(line, ShouldNotContainSubstring, "\033[0;34m")
+			So(line, ShouldNotContainSubstring, "\033[0;33m")
+		})
+
+		Convey("It should write no escape in ColorNone", func() {
+			l.WithColorScope(ColorNone).Info("hello")
+			So(out.String(), ShouldNotContainSubstring, "\033[")
+			So(out.String(), ShouldStartWith, "[][INFO]  ")
+		})
+
+		Convey("It should color the whole line with the level color", func() {
+			l.WithColorWholeLine().Error("failed")
+			line := out.String()
+			So(line, ShouldStartWith, "\033[0m\033[0;31m[][ERROR] ")
+			So(line, ShouldEndWith, "failed\033[0m\n")
+			So(strings.Count(line, "\033["), ShouldEqual, 3)
+		})
+
+		Convey("It should not color the line with ColorNone", func() {
+			l.WithColorWholeLine().WithColorScope(ColorNone).Error("failed")
+			So(out.String(), ShouldNotContainSubstring, "\033[")
+		})
+
+		Convey("It should color the message with the level color", func() {
+			l.WithColorMessage().Error("failed")
+			So(out.String(), ShouldEndWith, " \033[0m\033[0;31mfailed\033[0m\n")
+			So(out.String(), ShouldContainSubstring, "\033[0;34m")
+		})
+
+		Convey("It should reset the message color before the fields", func() {
+			l.WithColorMessage().WithFields(Fields{"code": 7}).Error("failed")
+			So(out.String(), ShouldEndWith, " \033[0m\033[0;31mfailed\033[0m code=7\n")
+		})
+	})
+
+	Convey("Given colored logger without caller", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Color: true}).WithoutCaller().WithColorMessage()
+
+		Convey("It should reset the message color before the newline", func() {
+			l.Warn("careful")
+			So(out.String(), ShouldEndWith, "  \033[0;33mcareful\033[0m\n")
+		})
+
+		Convey("It should not color the message with ColorNone", func() {
+			l.WithColorScope(ColorNone).Warn("careful")
+			So(out.String(), ShouldEqual, "[][WARN]  careful\n")
+		})
+	})
+}
+
+func TestTheme(t *testing.T) {
+	Convey("Given colored logger with timestamp and caller", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Color: true, Timestamp: true}).WithCallerForAll()
+
+		Convey("It should use the theme colors", func() {
+			l.WithTheme(HighContrastTheme()).Warn("careful")
+			So(out.String(), ShouldStartWith, "\033[0m[]\033[1;93m[WARN]\033[0m  \033[1;94m")
+			So(out.String(), ShouldContainSubstring, "\033[1;97mgithub.com/csturiale/go-log.TestTheme")
+		})
+
+		Convey("It should keep the current colors missing from the theme", func() {
+			l.WithTheme(Theme{Info: colorful.ColorCyan}).Info("hello")
+			So(out.String(), ShouldStartWith, "\033[0m[]\033[0;36m[INFO]\033[0m  \033[0;34m")
+			So(out.String(), ShouldContainSubstring, "\033[0;33mgithub.com/csturiale/go-log.TestTheme")
+		})
+
+		Convey("It should write the same line with the default theme", func() {
+			l.WithFrozenClock(time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC))
+			for _, logger := range []*Logger{l, l.Clone().WithTheme(DefaultTheme())} {
+				logger.Info("hello")
+			}
+			lines := strings.SplitAfter(out.String(), "\n")
+			So(lines[0], ShouldEqual, lines[1])
+		})
+	})
+}
+
+func TestObserve(t *testing.T) {
+	Convey("Given logger with debug and timestamp", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Debug: true, Timestamp: true})
+
+		Convey("It should pass the configuration snapshot", func() {
+			var debug, timestamp bool
+			l.Observe(func(c Config) {
+				debug, timestamp = c.Debug, c.Timestamp
+			})
+			So(debug, ShouldBeTrue)
+			So(timestamp, ShouldBeTrue)
+		})
+
+		Convey("It should not change the logger when the copy is modified", func() {
+			l.Observe(func(c Config) {
+				c.Debug = false
+			})
+			So(l.IsDebug(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestInlineFields(t *testing.T) {
+	Convey("Given logger with fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"b": 2, "a": 1})
+
+		Convey("It should write the fields sorted by key", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello a=1 b=2\n")
+		})
+
+		Convey("It should expand a sole Fields argument", func() {
+			l.Info(Fields{"c": "x y"})
+			So(out.String(), ShouldEqual, "[][INFO]  a=1 b=2 c=\"x y\"\n")
+		})
+
+		Convey("It should let the inline fields take precedence", func() {
+			l.Warn(Fields{"a": 3})
+			So(out.String(), ShouldEqual, "[][WARN]  a=3 b=2\n")
+		})
+
+		Convey("It should not write the inline fields after Close", func() {
+			l.Close()
+			So(l.Output(1, l.prefix(LevelInfo), "hello"), ShouldEqual, ErrClosed)
+			l.Info(Fields{"c": 3})
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should collapse the repeated inline fields only", func() {
+			l.WithDedup(time.Hour)
+			for i := 0; i < 3; i++ {
+				l.Info(Fields{"c": i / 2})
+			}
+			l.WithoutDedup()
+			So(out.String(), ShouldEqual, "[][INFO]  a=1 b=2 c=0\n[][INFO]  last message repeated 1 times a=1 b=2\n[][INFO]  a=1 b=2 c=1\n")
+		})
+
+		Convey("It should expand a struct with log tags", func() {
+			l.WithFormat(FormatJSON).Info(&struct {
+				User   string `log:"user"`
+				Secret string `log:"-"`
+				Count  int    `log:""`
+				Other  int
+			}{User: "al", Secret: "s", Count: 4, Other: 5})
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"","a":1,"b":2,"user":"al","Count":4}`+"\n")
+		})
+
+		Convey("It should print a struct without log tags as usual", func() {
+			l.Info(struct{ A int }{1})
+			So(out.String(), ShouldEqual, "[][INFO]  {1} a=1 b=2\n")
+		})
+
+		Convey("It should keep the caller of the log method", func() {
+			l.WithCallerForAll().Info(Fields{"c": 1})
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+	})
+}
+
+func TestWithField(t *testing.T) {
+	Convey("Given logger with fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"b": 2})
+
+		Convey("It should append the single field to a child logger", func() {
+			l.WithField("a", 1).Info("hello")
+			l.Info("parent")
+			So(out.String(), ShouldEqual, "[][INFO]  hello b=2 a=1\n[][INFO]  parent b=2\n")
+		})
+
+		Convey("It should replace the value of a field with the same key", func() {
+			l.WithField("b", 3).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello b=3\n")
+		})
+	})
+}
+
+func TestEntryCallback(t *testing.T) {
+	Convey("Given logger with entry callback", t, func() {
+		var out testWriter
+		var entries []LogEntry
+		now := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+		l := newLogger(Config{Out: &out, Prefix: "app"}).WithFrozenClock(now).WithLocation(time.UTC).
+			WithEntryCallback(func(entry LogEntry) {
+				entries = append(entries, entry)
+			})
+
+		Convey("It should pass the written line as entry", func() {
+			l.WithField("user", "al").Warn("disk low")
+			So(out.String(), ShouldEqual, "[app][WARN]  disk low user=al\n")
+			So(entries, ShouldResemble, []LogEntry{{
+				Level:     LevelWarn,
+				Prefix:    "app",
+				Message:   "disk low",
+				Timestamp: now,
+				Fields:    Fields{"user": "al"},
+			}})
+		})
+
+		Convey("It should set the caller when the caller info is on", func() {
+			l.WithCallerForAll().Info("hello")
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0].File, ShouldEqual, "log_test.go")
+			So(entries[0].Line, ShouldBeGreaterThan, 0)
+			So(entries[0].Func, ShouldStartWith, "github.com/csturiale/go-log.TestEntryCallback")
+		})
+
+		Convey("It should skip the lines which are not written", func() {
+			l.Debug("skipped")
+			l.Quiet().Info("quiet")
+			So(entries, ShouldBeEmpty)
+		})
+
+		Convey("It should allow logging from the callback", func() {
+			l.WithEntryCallback(func(entry LogEntry) {
+				if entry.Level == LevelWarn {
+					l.Info("warning seen")
+				}
+			}).Warn("failed")
+			So(out.String(), ShouldEqual, "[app][WARN]  failed\n[app][INFO]  warning seen\n")
+		})
+	})
+
+	Convey("Given logger with entry buffering", t, func() {
+		l := NewDiscardLogger().WithEntryBuffering(3)
+		messages := func(entries []LogEntry) []string {
+			var msgs []string
+			for _, entry := range entries {
+				msgs = append(msgs, entry.Message)
+			}
+			return msgs
+		}
+
+		Convey("It should keep the last entries from the oldest", func() {
+			for _, msg := range []string{"one", "two", "three", "four"} {
+				l.Info(msg)
+			}
+			So(messages(l.RecentEntries(0)), ShouldResemble, []string{"two", "three", "four"})
+			So(messages(l.RecentEntries(2)), ShouldResemble, []string{"three", "four"})
+		})
+
+		Convey("It should keep the entries of the children", func() {
+			l.Info("parent")
+			l.WithField("a", 1).Info("child")
+			So(messages(l.RecentEntries(5)), ShouldResemble, []string{"parent", "child"})
+		})
+
+		Convey("It should return nil when the buffering is off", func() {
+			So(NewDiscardLogger().RecentEntries(1), ShouldBeNil)
+			So(l.WithEntryBuffering(0).RecentEntries(1), ShouldBeNil)
+		})
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given logger with middlewares", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app"}).WithField("user", "al")
+		tag := func(name string) Middleware {
+			return func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					entry.Message += " " + name
+					return next(entry)
+				}
+			}
+		}
+
+		Convey("It should call the middlewares from the first one", func() {
+			l.Use(tag("a"), tag("b")).Use(tag("c")).Info("hello")
+			So(out.String(), ShouldEqual, "[app][INFO]  hello a b c user=al\n")
+		})
+
+		Convey("It should let the middleware add a field without logger fields", func() {
+			newLogger(Config{Out: &out}).Use(func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					entry.Fields["region"] = "eu"
+					return next(entry)
+				}
+			}).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello region=eu\n")
+		})
+
+		Convey("It should keep the time zone of the clock", func() {
+			now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))
+			newLogger(Config{Out: &out}).WithFrozenClock(now).WithTimestamp().Use(tag("a")).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  2024/01/02 03:04:05 hello a\n")
+		})
+
+		Convey("It should call the prefix function under the write lock", func() {
+			locked := false
+			l.WithDynamicPrefix(func() string {
+				if locked = !l.mu.TryLock(); !locked {
+					l.mu.Unlock()
+				}
+				return "dyn"
+			}).Use(tag("a")).Info("hello")
+			So(locked, ShouldBeTrue)
+			So(out.String(), ShouldEqual, "[dyn][INFO]  hello a user=al\n")
+		})
+
+		Convey("It should write the fields changed by the middleware", func() {
+			l.Use(func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					entry.Fields["user"] = "[REDACTED]"
+					entry.Fields["env"] = "prod"
+					return next(entry)
+				}
+			}).Info("hello")
+			So(out.String(), ShouldEqual, "[app][INFO]  hello user=[REDACTED] env=prod\n")
+		})
+
+		Convey("It should write the level and prefix of the entry", func() {
+			l.Use(func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					entry.Level = LevelError
+					entry.Prefix = "db"
+					return next(entry)
+				}
+			}).WithoutCaller().Info("slow query")
+			So(out.String(), ShouldEqual, "[db][ERROR] slow query user=al\n")
+		})
+
+		Convey("It should drop the line when next is not called", func() {
+			var levels []Level
+			observed := newLogger(Config{Out: &out, Prefix: "app", MetricsObserver: func(level Level) {
+				levels = append(levels, level)
+			}})
+			sample := observed.WithField("user", "al").Use(func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					if entry.Level < LevelWarn {
+						return nil
+					}
+					return next(entry)
+				}
+			})
+			sample.Info("dropped")
+			sample.Warn("kept")
+			So(out.String(), ShouldEqual, "[app][WARN]  kept user=al\n")
+			So(levels, ShouldResemble, []Level{LevelWarn})
+		})
+
+		Convey("It should forward the entry to another destination", func() {
+			var messages []string
+			l.Use(func(next HandlerFunc) HandlerFunc {
+				return func(entry LogEntry) error {
+					messages = append(messages, entry.Message)
+					return next(entry)
+				}
+			}).Info("hello")
+			So(messages, ShouldResemble, []string{"hello"})
+			So(out.String(), ShouldEqual, "[app][INFO]  hello user=al\n")
+		})
+
+		Convey("It should pass the written entry to the entry callback", func() {
+			var entries []LogEntry
+			l.Use(tag("a")).WithEntryCallback(func(entry LogEntry) {
+				entries = append(entries, entry)
+			}).Info("hello")
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0].Message, ShouldEqual, "hello a")
+		})
+
+		Convey("It should not share the chain with the clones", func() {
+			l.Clone().Use(tag("a"))
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[app][INFO]  hello user=al\n")
+		})
+	})
+}
+
+func TestTraceContext(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		header := http.Header{}
+
+		Convey("It should attach the trace context fields", func() {
+			l.WithTraceContext("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", "01").Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01\n")
+		})
+
+		Convey("It should skip the empty values", func() {
+			l.WithTraceContext("4bf92f3577b34da6a3ce929d0e0e4736", "", "").Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello trace_id=4bf92f3577b34da6a3ce929d0e0e4736\n")
+		})
+
+		Convey("It should parse the traceparent header", func() {
+			header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			l.WithTraceContextFromHeader(header).WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}`+"\n")
+		})
+
+		Convey("It should accept the extra parts of the later versions", func() {
+			header.Set("Traceparent", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+			l.WithTraceContextFromHeader(header).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=00\n")
+		})
+
+		Convey("It should return the logger itself on a missing or invalid header", func() {
+			So(l.WithTraceContextFromHeader(header), ShouldEqual, l)
+			for _, value := range []string{
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+			} {
+				header.Set("Traceparent", value)
+				So(l.WithTraceContextFromHeader(header), ShouldEqual, l)
+			}
+		})
+	})
+}
+
+func TestFdWriterBridge(t *testing.T) {
+	Convey("Given bridge over a bytes buffer", t, func() {
+		var buf bytes.Buffer
+		w := NewFdWriterBridge(&buf, 0)
+		l := newLogger(Config{Out: w})
+
+		Convey("It should write the log to the buffer", func() {
+			l.Info("hello")
+			So(buf.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should return the given file descriptor", func() {
+			So(w.Fd(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestTeeWriter(t *testing.T) {
+	Convey("Given logger writing to a tee", t, func() {
+		var primary, secondary testWriter
+		l := newLogger(Config{Out: NewTeeWriter(&primary, &secondary, LevelError)})
+
+		Convey("When logging below and at the minimum level", func() {
+			l.Info("hello")
+			l.Warn("careful")
+			l.WithCallerForLevel(LevelError, false).Error("failed")
+
+			Convey("It should write every line to the primary", func() {
+				So(primary.String(), ShouldEqual, "[][INFO]  hello\n[][WARN]  careful\n[][ERROR] failed\n")
+			})
+
+			Convey("It should copy the error to the secondary", func() {
+				So(secondary.String(), ShouldEqual, "[][ERROR] failed\n")
+			})
+		})
+
+		Convey("It should return the primary file descriptor", func() {
+			So(l.config.Load().Out.Fd(), ShouldEqual, primary.Fd())
+		})
+	})
+}
+
+func TestSetLevelColor(t *testing.T) {
+	Convey("Given two colored loggers", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Color: true})
+		other := newLogger(Config{Out: &out, Color: true})
+
+		Convey("When the warning color is changed on the first logger", func() {
+			So(l.SetLevelColor(LevelWarn, colorful.ColorYellow), ShouldBeNil)
+
+			Convey("It should only recolor the first logger", func() {
+				l.Warn("careful")
+				So(out.String(), ShouldContainSubstring, string(colorful.ColorYellow)+"[WARN]\033[0m  careful")
+				out.Reset()
+				other.Warn("careful")
+				So(out.String(), ShouldContainSubstring, string(WarnPrefix.Color))
+			})
+
+			Convey("It should keep the plain tag", func() {
+				l.WithoutColor().Warn("careful")
+				So(out.String(), ShouldEqual, "[][WARN]  careful\n")
+			})
+		})
+
+		Convey("It should reject unknown level", func() {
+			So(l.SetLevelColor(Level(42), colorful.ColorRed), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestLevelStyle(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("It should pad the full tags to the same width", func() {
+			l.Warn("careful")
+			l.WithCallerForLevel(LevelError, false).Error("failed")
+			So(out.String(), ShouldEqual, "[][WARN]  careful\n[][ERROR] failed\n")
+		})
+
+		Convey("It should write three letters tag in short style", func() {
+			l.WithLevelStyle(LevelStyleShort).Warn("careful")
+			So(out.String(), ShouldEqual, "[][WRN] careful\n")
+		})
+
+		Convey("It should write single letter tag in letter style", func() {
+			l.WithLevelStyle(LevelStyleLetter).Info("hello")
+			So(out.String(), ShouldEqual, "[][I] hello\n")
+		})
+
+		Convey("It should keep the level color in letter style", func() {
+			l.WithColor().WithLevelStyle(LevelStyleLetter).Info("hello")
+			So(out.String(), ShouldEqual, "\033[0m[]\033[0;32m[I]\033[0m hello\n")
+		})
+	})
+}
+
+func TestLevelFormat(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app"}).WithCallerForLevel(LevelError, false)
+
+		Convey("It should write the level name followed by a colon in plain format", func() {
+			l.WithLevelFormat(LevelFormatPlain).Info("hello")
+			So(out.String(), ShouldEqual, "[app] INFO: hello\n")
+		})
+
+		Convey("It should pad the level name in padded format", func() {
+			l.WithLevelFormat(LevelFormatPadded)
+			l.Info("hello")
+			l.Error("failed")
+			So(out.String(), ShouldEqual, "[app] INFO  hello\n[app] ERROR failed\n")
+		})
+
+		Convey("It should write no level in none format", func() {
+			l.WithLevelFormat(LevelFormatNone).Warn("careful")
+			So(out.String(), ShouldEqual, "[app] careful\n")
+		})
+
+		Convey("It should combine with the short style", func() {
+			l.WithLevelFormat(LevelFormatPlain).WithLevelStyle(LevelStyleShort).Warn("careful")
+			So(out.String(), ShouldEqual, "[app] WRN: careful\n")
+		})
+
+		Convey("It should omit the empty prefix", func() {
+			newLogger(Config{Out: &out}).WithLevelFormat(LevelFormatPlain).Info("hello")
+			So(out.String(), ShouldEqual, "INFO: hello\n")
+		})
+
+		Convey("It should keep the level color", func() {
+			l.WithColor().WithLevelFormat(LevelFormatPlain).Info("hello")
+			So(out.String(), ShouldEqual, "\033[0m[app] \033[0;32mINFO\033[0m: hello\n")
+		})
+	})
+}
+
+func TestHideLevel(t *testing.T) {
+	Convey("Given logger with hidden level", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, HideLevel: true})
+
+		Convey("It should write only the message", func() {
+			l.Info("hello")
+			l.Warn("careful")
+			So(out.String(), ShouldEqual, "hello\ncareful\n")
+		})
+
+		Convey("It should still filter by level", func() {
+			l.Debug("hidden")
+			l.WithLevel(LevelError).Warn("hidden")
+			So(out.String(), ShouldBeEmpty)
+		})
+
+		Convey("It should keep the prefix, timestamp and caller", func() {
+			now := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+			l.SetPrefix("app").WithFrozenClock(now).WithLocation(time.UTC).WithTimestamp().WithCallerFormat(CallerFile).Error("failed")
+			So(out.String(), ShouldStartWith, "[app] 2017/03/04 05:06:07 log_test.go:")
+			So(out.String(), ShouldEndWith, " failed\n")
+		})
+
+		Convey("It should write no level color", func() {
+			l.WithColor().WithColorScope(ColorLevelOnly).Info("hello")
+			So(out.String(), ShouldEqual, "\033[0mhello\n")
+		})
+
+		Convey("It should write the level again with WithoutHideLevel", func() {
+			l.WithoutHideLevel().Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+}
+
+func TestFloatFields(t *testing.T) {
+	Convey("Given logger with float fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"cpu": float32(0.1), "latency": 12.5})
+
+		Convey("It should write the floats in text format", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello cpu=0.1 latency=12.5\n")
+		})
+
+		Convey("It should write the floats in JSON format", func() {
+			l.WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","cpu":0.1,"latency":12.5}`+"\n")
+		})
+
+		Convey("It should write NaN as string in JSON format", func() {
+			l.WithFormat(FormatJSON).Info(Fields{"rate": math.NaN()})
+			So(out.String(), ShouldContainSubstring, `"rate":"NaN"`)
+		})
+	})
+}
+
+func TestDurationFields(t *testing.T) {
+	Convey("Given logger with duration field", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"latency": 1234 * time.Microsecond})
+
+		Convey("It should write the duration in human readable format", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello latency=1.234ms\n")
+		})
+
+		Convey("It should write the duration as string in JSON format", func() {
+			l.WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","latency":"1.234ms"}`+"\n")
+		})
+
+		Convey("It should write the duration as nanoseconds if requested", func() {
+			l.WithFormat(FormatJSON).WithDurationFormat(DurationNanos).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","latency":1234000}`+"\n")
+		})
+	})
+
+	Convey("Given logger with time field", t, func() {
+		var out testWriter
+		at := time.Date(2017, 3, 4, 5, 6, 7, 500000000, time.FixedZone("", 3600))
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"at": at})
+
+		Convey("It should write the time in RFC 3339 format", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello at=2017-03-04T05:06:07.5+01:00\n")
+		})
+
+		Convey("It should write the time in RFC 3339 format in JSON", func() {
+			l.WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","at":"2017-03-04T05:06:07.5+01:00"}`+"\n")
+		})
+	})
+}
+
+func TestIntegerFields(t *testing.T) {
+	Convey("Given logger with integer fields", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("It should write the signed integer in decimal", func() {
+			l.WithFields(Fields{"delta": int8(-3)}).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello delta=-3\n")
+		})
+
+		Convey("It should write the unsigned integer in decimal", func() {
+			l.WithFields(Fields{"size": uint64(1<<64 - 1)}).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello size=18446744073709551615\n")
+		})
+
+		Convey("It should write the integer as number in JSON format", func() {
+			l.WithFormat(FormatJSON).WithFields(Fields{"size": uint32(42)}).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","size":42}`+"\n")
+		})
+	})
+}
+
+func TestBytesFields(t *testing.T) {
+	Convey("Given logger with bytes field", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithFields(Fields{"hash": []byte{0x0b, 0xad, 0xca, 0xfe}})
+
+		Convey("It should write the bytes in hexadecimal by default", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello hash=0badcafe\n")
+		})
+
+		Convey("It should write the bytes in base64 if requested", func() {
+			l.WithBytesEncoding(BytesBase64).WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","hash":"C63K/g=="}`+"\n")
+		})
+
+		Convey("It should write the bytes as string if requested", func() {
+			l.WithBytesEncoding(BytesString).WithFields(Fields{"hash": []byte("a b")}).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello hash=\"a b\"\n")
+		})
+
+		Convey("It should truncate the encoded bytes like the message", func() {
+			l = newLogger(Config{Out: &out, MaxMessageLen: 4}).WithFields(Fields{"hash": []byte{0x0b, 0xad, 0xca, 0xfe}})
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hell...[truncated 1 bytes] hash=\"0bad...[truncated 4 bytes]\"\n")
+		})
+	})
+}
+
+func TestTimePrecision(t *testing.T) {
+	Convey("Given record logged 7ms after the second", t, func() {
+		now := time.Date(2017, 3, 4, 5, 6, 7, 7008009, time.UTC)
+		r := &record{now: now, prefix: InfoPrefix, data: []byte("hello")}
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		Convey("It should write the zero padded fraction in text format", func() {
+			l := newLogger(Config{Out: discardWriter{}, Timestamp: true})
+			for precision, want := range map[TimePrecision]string{
+				PrecisionSeconds: "[][INFO]  2017/03/04 05:06:07 hello\n",
+				PrecisionMillis:  "[][INFO]  2017/03/04 05:06:07.007 hello\n",
+				PrecisionMicros:  "[][INFO]  2017/03/04 05:06:07.007008 hello\n",
+				PrecisionNanos:   "[][INFO]  2017/03/04 05:06:07.007008009 hello\n",
+			} {
+				buf.Reset()
+				l.WithTimePrecision(precision)
+				l.format(buf, l.config.Load(), r)
+				So(string(buf.Bytes()), ShouldEqual, want)
+			}
+		})
+
+		Convey("It should write the fraction in JSON format", func() {
+			l := newLogger(Config{Out: discardWriter{}, Timestamp: true, Format: FormatJSON, TimePrecision: PrecisionMillis})
+			buf.Reset()
+			l.format(buf, l.config.Load(), r)
+			So(string(buf.Bytes()), ShouldEqual, `{"time":"2017-03-04T05:06:07.007Z","level":"INFO","msg":"hello"}`+"\n")
+		})
+	})
+}
+
+func TestElapsed(t *testing.T) {
+	Convey("Given logger with elapsed timestamp", t, func() {
+		start := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+		l := newLogger(Config{Out: discardWriter{}, Timestamp: true, ElapsedSince: start})
+		buf := getBuffer()
+		defer putBuffer(buf)
+
+		Convey("It should write the time elapsed since the start", func() {
+			l.format(buf, l.config.Load(), &record{now: start.Add(1234 * time.Millisecond), prefix: InfoPrefix, data: []byte("hello")})
+			So(string(buf.Bytes()), ShouldEqual, "[][INFO]  +00:01.234 hello\n")
+		})
+
+		Convey("It should add the hours after an hour", func() {
+			l.format(buf, l.config.Load(), &record{now: start.Add(time.Hour + 2*time.Minute + 3*time.Second), prefix: InfoPrefix, data: []byte("hello")})
+			So(string(buf.Bytes()), ShouldEqual, "[][INFO]  +1:02:03.000 hello\n")
+		})
+
+		Convey("It should start from now with WithElapsed", func() {
+			var out testWriter
+			newLogger(Config{Out: &out}).WithElapsed().Info("hello")
+			So(out.String(), ShouldStartWith, "[][INFO]  +00:00.")
+		})
+	})
+}
+
+func TestLevelWriter(t *testing.T) {
+	Convey("Given logger with an error writer", t, func() {
+		var out, errOut testWriter
+		l := newLogger(Config{Out: &out}).WithCallerForLevel(LevelError, false).WithLevelWriter(LevelError, &errOut)
+
+		Convey("When logging info and error", func() {
+			l.Info("hello")
+			l.Error("failed")
+
+			Convey("It should write each line to its writer", func() {
+				So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+				So(errOut.String(), ShouldEqual, "[][ERROR] failed\n")
+			})
+		})
+
+		Convey("It should restore Out with nil writer", func() {
+			l.WithLevelWriter(LevelError, nil).Error("failed")
+			So(out.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+
+		Convey("It should wire errors and fatal to stderr", func() {
+			c := l.WithErrorsToStderr().config.Load()
+			So(c.writers[LevelError.index()], ShouldEqual, os.Stderr)
+			So(c.writers[LevelFatal.index()], ShouldEqual, os.Stderr)
+			So(c.writers[LevelWarn.index()], ShouldBeNil)
+		})
+	})
+}
+
+func TestClock(t *testing.T) {
+	Convey("Given logger with a fixed clock", t, func() {
+		var out testWriter
+		l := newLogger(Config{
+			Out:       &out,
+			Timestamp: true,
+			Clock:     func() time.Time { return time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC) },
+		})
+
+		Convey("It should write the clock time", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  2017/03/04 05:06:07 hello\n")
+		})
+
+		Convey("It should write the clock time in JSON format", func() {
+			l.WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldEqual, `{"time":"2017-03-04T05:06:07Z","level":"INFO","msg":"hello"}`+"\n")
+		})
+
+		Convey("It should write the time in the configured location", func() {
+			l.WithLocation(time.FixedZone("EST", -5*3600)).Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  2017/03/04 00:06:07 hello\n")
+		})
+
+		Convey("It should write the offset of the location in JSON format", func() {
+			l.WithLocation(time.FixedZone("EST", -5*3600)).WithFormat(FormatJSON).Info("hello")
+			So(out.String(), ShouldStartWith, `{"time":"2017-03-04T00:06:07-05:00"`)
+		})
+	})
+
+	Convey("Given logger with timestamp", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Timestamp: true})
+
+		Convey("It should write the frozen time on every line", func() {
+			l.WithFrozenClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)).Info("one")
+			l.Info("two")
+			So(out.String(), ShouldEqual, "[][INFO]  2020/01/02 03:04:05 one\n[][INFO]  2020/01/02 03:04:05 two\n")
+		})
+
+		Convey("It should call the clock for every line", func() {
+			tick := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			l.WithClock(func() time.Time {
+				tick = tick.Add(time.Second)
+				return tick
+			}).Info("one")
+			l.Info("two")
+			So(out.String(), ShouldEqual, "[][INFO]  2020/01/02 03:04:06 one\n[][INFO]  2020/01/02 03:04:07 two\n")
+		})
+
+		Convey("It should go back to the current time without clock", func() {
+			l.WithFrozenClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)).WithClock(nil).Info("now")
+			So(out.String(), ShouldNotContainSubstring, "2020/01/02")
+		})
+	})
+}
+
+func TestRedaction(t *testing.T) {
+	Convey("Given logger with redaction", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithRedaction(regexp.MustCompile(`password=\S+`))
+
+		Convey("It should redact the matching text", func() {
+			l.Infof("login user=al password=hunter2 ok")
+			So(out.String(), ShouldEqual, "[][INFO]  login user=al [REDACTED] ok\n")
+		})
+
+		Convey("It should apply the redaction function after the patterns", func() {
+			l.WithRedactionFunc(func(s string) string {
+				return regexp.MustCompile(`\d{12}(\d{4})`).ReplaceAllString(s, "************$1")
+			}).Infof("card 4111111111111111 password=x")
+			So(out.String(), ShouldEqual, "[][INFO]  card ************1111 [REDACTED]\n")
+		})
+
+		Convey("It should redact before the color codes", func() {
+			l.WithColor().WithCallerForLevel(LevelInfo, true).Infof("password=x")
+			So(out.String(), ShouldEndWith, "\033[0m[REDACTED]\n")
+		})
+
+		Convey("It should not change the caller data", func() {
+			data := []byte("password=x")
+			l.OutputBytes(1, InfoPrefix, data)
+			So(string(data), ShouldEqual, "password=x")
+		})
+	})
+
+	Convey("Given logger redacting card numbers", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithRedaction(regexp.MustCompile(`\d{16}`))
+
+		Convey("It should redact the inline field values", func() {
+			l.Infow("paid", "card", "4111111111111111", "amount", 12)
+			So(out.String(), ShouldEqual, "[][INFO]  paid card=[REDACTED] amount=12\n")
+		})
+
+		Convey("It should redact the logger field values", func() {
+			l.WithField("card", 4111111111111111).Info("paid")
+			So(out.String(), ShouldEqual, "[][INFO]  paid card=[REDACTED]\n")
+		})
+
+		Convey("It should redact the field values in JSON format", func() {
+			l.WithFormat(FormatJSON).Infow("paid", "card", "4111111111111111", "amount", 12)
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"paid","card":"[REDACTED]","amount":12}`+"\n")
+		})
+	})
+}
+
+func TestCallerMode(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+
+		Convey("It should print the caller of info with WithCaller", func() {
+			l.WithCaller().Info("hello")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+
+		Convey("It should hide the caller of error with WithoutCaller", func() {
+			l.WithoutCaller().Error("failed")
+			So(out.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+
+		Convey("It should restore the prefix setting with WithDefaultCaller", func() {
+			l.WithoutCaller().WithDefaultCaller().Error("failed")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+	})
+}
+
+func TestCallerFormat(t *testing.T) {
+	Convey("Given logger with caller info", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithCaller()
+
+		Convey("It should write the function by default", func() {
+			l.Info("hello")
+			So(out.String(), ShouldStartWith, "[][INFO]  github.com/csturiale/go-log.TestCallerFormat")
+		})
+
+		Convey("It should write the file and line with CallerFile", func() {
+			l.WithCallerFormat(CallerFile).Info("hello")
+			So(out.String(), ShouldStartWith, "[][INFO]  log_test.go:")
+		})
+
+		Convey("It should write the path relative to the working directory with CallerPath", func() {
+			defer func(dir string) { workDir = dir }(workDir)
+			file := filepath.Join(filepath.Base(workDir), "log_test.go")
+			workDir = filepath.Dir(workDir)
+			l.WithCallerFormat(CallerPath).Info("hello")
+			So(out.String(), ShouldStartWith, "[][INFO]  "+file+":")
+		})
+
+		Convey("It should keep the absolute path outside of the working directory", func() {
+			defer func(dir string) { workDir = dir }(workDir)
+			file := filepath.Join(workDir, "log_test.go")
+			workDir = t.TempDir()
+			l.WithCallerFormat(CallerPath).Info("hello")
+			So(out.String(), ShouldStartWith, "[][INFO]  "+file+":")
+		})
+	})
+}
+
+func TestMinCallerWidth(t *testing.T) {
+	Convey("Given terminal 60 columns wide", t, func() {
+		var out testWriter
+		defer func(width func(uintptr) int) { outputWidth = width }(outputWidth)
+		outputWidth = func(fd uintptr) int { return 60 }
+		l := newLogger(Config{Out: &out, MinCallerWidth: 80})
+
+		Convey("It should hide the caller info below the minimum width", func() {
+			l.Error("failed")
+			So(out.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+
+		Convey("It should keep the caller info when forced with WithCaller", func() {
+			l.WithCaller().Error("failed")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+
+		Convey("It should keep the caller info on a wide enough terminal", func() {
+			l.WithMinCallerWidth(60).Error("failed")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+
+		Convey("It should measure the width again when the output change", func() {
+			outputWidth = func(fd uintptr) int { return 0 }
+			l.SetOutput(&out).Error("failed")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+	})
+
+	Convey("Given output which is not a terminal", t, func() {
+		r, w, err := os.Pipe()
+		So(err, ShouldBeNil)
+		defer r.Close()
+		defer w.Close()
+
+		Convey("It should have no width", func() {
+			So(terminalWidth(w.Fd()), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestLoggerWrite(t *testing.T) {
+	Convey("Given logger used as io.Writer", t, func() {
+		var out testWriter
+		var w io.Writer = newLogger(Config{Out: &out, Timestamp: true, Clock: func() time.Time {
+			return time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+		}})
+
+		Convey("It should write the bytes as an info message", func() {
+			n, err := w.Write([]byte(`{"id":7}` + "\n"))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 9)
+			So(out.String(), ShouldEqual, "[][INFO]  2017/03/04 05:06:07 {\"id\":7}\n")
+		})
+
+		Convey("It should return the error of closed logger", func() {
+			w.(*Logger).Close()
+			_, err := w.Write([]byte("hello"))
+			So(err, ShouldEqual, ErrClosed)
+		})
+	})
+}
+
+func TestAsync(t *testing.T) {
+	Convey("Given logger with async output", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller().WithAsync(16)
+
+		Convey("It should write the queued lines on flush", func() {
+			l.Info("hello")
+			l.Warn("careful")
+			So(l.flush(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n[][WARN]  careful\n")
+		})
+
+		Convey("It should drain the queue before Fatal exits", func() {
+			var written string
+			l.WithExitFunc(func(code int) {
+				written = out.String()
+			})
+			l.Info("hello")
+			l.Fatal("failed")
+			So(written, ShouldEqual, "[][INFO]  hello\n[][FATAL] failed\n")
+		})
+
+		Convey("It should drain the queue before Panic", func() {
+			var recovered interface{}
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				l.Panicf("failed %d", 1)
+			}()
+			So(recovered, ShouldEqual, "failed 1")
+			So(out.String(), ShouldEqual, "[][FATAL] failed 1\n")
+		})
+
+		Convey("It should drain the queue on close", func() {
+			l.Info("hello")
+			So(l.Close(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+
+	Convey("Given logger with async failing output", t, func() {
+		l := newLogger(Config{Out: &failWriter{}}).WithAsync(16)
+
+		Convey("It should return the write error on flush", func() {
+			So(l.Output(1, InfoPrefix, "hello"), ShouldBeNil)
+			So(l.flush(), ShouldEqual, io.ErrClosedPipe)
+			So(l.flush(), ShouldBeNil)
+		})
+	})
+}
+
+func TestWriteBuffer(t *testing.T) {
+	Convey("Given logger with write buffer", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithWriteBuffer(64, 0)
+
+		Convey("It should keep the lines until the buffer is full", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "")
+			l.Info("a line long enough to overflow the buffer size")
+			So(out.String(), ShouldStartWith, "[][INFO]  hello\n")
+		})
+
+		Convey("It should write the lines on flush", func() {
+			l.Info("hello")
+			So(l.flush(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should write the lines on close", func() {
+			l.Info("hello")
+			So(l.Close(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should write the fatal message before exiting", func() {
+			code := -1
+			l.WithoutCaller().WithExitFunc(func(c int) {
+				code = c
+				So(out.String(), ShouldEqual, "[][INFO]  hello\n[][FATAL] failed\n")
+			})
+			l.Info("hello")
+			l.Fatalf("failed")
+			So(code, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given logger with write buffer in front of a tee writer", t, func() {
+		var out, errs testWriter
+		l := newLogger(Config{Out: NewTeeWriter(&out, &errs, LevelError)}).WithoutCaller().WithWriteBuffer(4096, 0)
+
+		Convey("It should pass the level of the lines to the output", func() {
+			l.Info("hello")
+			l.Error("failed")
+			l.Info("done")
+			So(l.flush(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n[][ERROR] failed\n[][INFO]  done\n")
+			So(errs.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+	})
+
+	Convey("Given logger with write buffer replaced", t, func() {
+		var out, other testWriter
+		l := newLogger(Config{Out: &out}).WithWriteBuffer(4096, time.Hour)
+		first := l.config.Load().Out.(*bufferedWriter)
+		l.Info("hello")
+
+		Convey("It should flush and stop the previous buffer on WithWriteBuffer", func() {
+			l.WithWriteBuffer(4096, time.Hour)
+			So(first.stopped(), ShouldBeTrue)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+			So(l.config.Load().Out.(*bufferedWriter).out, ShouldEqual, &out)
+		})
+
+		Convey("It should flush and stop the previous buffer on SetOutput", func() {
+			l.SetOutput(&other)
+			So(first.stopped(), ShouldBeTrue)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+
+	Convey("Given logger with buffered level writer", t, func() {
+		var out, errs testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller()
+		l.WithLevelWriter(LevelError, newBufferedWriter(&errs, 4096))
+
+		Convey("It should flush the level writer as well", func() {
+			l.Error("failed")
+			So(errs.String(), ShouldEqual, "")
+			So(l.flush(), ShouldBeNil)
+			So(errs.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+	})
+
+	Convey("Given logger with write buffer and flush interval", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithWriteBuffer(4096, 10*time.Millisecond)
+
+		Convey("It should write the lines after the interval", func() {
+			l.Info("hello")
+			time.Sleep(50 * time.Millisecond)
+			// The write buffer is empty so Close only stop the flusher
+			w := l.config.Load().Out.(*bufferedWriter)
+			w.mu.Lock()
+			buffered := w.bw.Buffered()
+			w.mu.Unlock()
+			So(buffered, ShouldEqual, 0)
+			So(l.Close(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+}
+
+func TestSugaredFields(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller()
+
+		Convey("It should write the keys and values as fields", func() {
+			l.Infow("request done", "status", 200, "path", "/foo")
+			So(out.String(), ShouldEqual, "[][INFO]  request done status=200 path=/foo\n")
+		})
+
+		Convey("It should encode the fields in JSON format", func() {
+			l.WithFormat(FormatJSON).Errorw("failed", "attempt", 3)
+			So(out.String(), ShouldEqual, `{"level":"ERROR","msg":"failed","attempt":3}`+"\n")
+		})
+
+		Convey("It should write the dangling argument as !BADKEY", func() {
+			l.Warnw("odd", "status", 200, "path")
+			So(out.String(), ShouldEqual, "[][WARN]  odd status=200 !BADKEY=path\n")
+		})
+
+		Convey("It should format the key which is not a string", func() {
+			l.Infow("hello", 1, "one")
+			So(out.String(), ShouldEqual, "[][INFO]  hello 1=one\n")
+		})
+
+		Convey("It should not write after Close", func() {
+			l.Close()
+			l.Infow("hello", "key", "value")
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should write nothing for disabled debug", func() {
+			l.Debugw("hidden", "key", "value")
+			l.Tracew("hidden", "key", "value")
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should write the debug line when enabled", func() {
+			l.WithDebug().Debugw("shown", "key", "value")
+			So(out.String(), ShouldEqual, "[][DEBUG] shown key=value\n")
+		})
+	})
+}
+
+func TestLazy(t *testing.T) {
+	Convey("Given logger without debug output", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller()
+		calls := 0
+		dump := Stringer(func() string {
+			calls++
+			return "big dump"
+		})
+		failure := LazyErr(func() error {
+			calls++
+			return errors.New("slow failure")
+		})
+
+		Convey("It should not format the values of a disabled line", func() {
+			l.Debugf("%v %v", dump, failure)
+			So(calls, ShouldEqual, 0)
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should format the values of a written line", func() {
+			l.Infof("%v: %v", dump, failure)
+			So(calls, ShouldEqual, 2)
+			So(out.String(), ShouldEqual, "[][INFO]  big dump: slow failure\n")
+		})
+
+		Convey("It should write the values as JSON string fields", func() {
+			l.WithFormat(FormatJSON).Infow("hello", "dump", dump, "error", failure)
+			So(out.String(), ShouldEqual, `{"level":"INFO","msg":"hello","dump":"big dump","error":"slow failure"}`+"\n")
+		})
+
+		Convey("It should write <nil> for the nil error", func() {
+			l.Info(LazyErr(func() error { return nil }))
+			So(out.String(), ShouldEqual, "[][INFO]  <nil>\n")
+		})
+	})
+}
+
+func TestGroup(t *testing.T) {
+	Convey("Given logger with prefix", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "myapp"}).WithoutCaller()
+
+		Convey("It should write the nested groups with their prefix and indentation", func() {
+			db := l.Group("db")
+			db.Info("connecting")
+			migration := db.Group("migration")
+			migration.Warn("slow step")
+			migration.EndGroup()
+			migration.EndGroup()
+			db.EndGroup()
+			l.Info("done")
+			So(out.String(), ShouldEqual, strings.Join([]string{
+				"[myapp][INFO]  begin group: db",
+				"[myapp:db][INFO]    connecting",
+				"[myapp:db][INFO]    begin group: migration",
+				"[myapp:db:migration][WARN]      slow step",
+				"[myapp:db][INFO]    end group: migration",
+				"[myapp][INFO]  end group: db",
+				"[myapp][INFO]  done",
+			}, "\n")+"\n")
+		})
+
+		Convey("It should share the write lock with the logger", func() {
+			So(l.Group("job").sharedState, ShouldEqual, l.sharedState)
+		})
+
+		Convey("It should not write the end line after Close", func() {
+			job := l.Group("job")
+			l.Close()
+			job.EndGroup()
+			So(out.String(), ShouldEqual, "[myapp][INFO]  begin group: job\n")
+		})
+
+		Convey("It should do nothing when ending a logger without group", func() {
+			l.EndGroup()
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should report the caller of EndGroup", func() {
+			job := l.WithCaller().WithCallerFormat(CallerFile).Group("job")
+			out.Reset()
+			_, _, line, _ := runtime.Caller(0)
+			job.EndGroup()
+			So(out.String(), ShouldEqual, fmt.Sprintf("[myapp][INFO]  log_test.go:%d end group: job\n", line+1))
+		})
+	})
+}
+
+func TestFatalErr(t *testing.T) {
+	Convey("Given logger with exit function", t, func() {
+		var out testWriter
+		exited := false
+		l := newLogger(Config{Out: &out}).WithoutCaller().WithExitFunc(func(int) {
+			exited = true
+		})
+
+		Convey("It should return the fatal message as error without exiting", func() {
+			err := l.FatalErr("disk", "full")
+			So(err, ShouldBeError, "disk full")
+			So(out.String(), ShouldEqual, "[][FATAL] disk full\n")
+			So(exited, ShouldBeFalse)
+		})
+
+		Convey("It should return the formatted fatal message as error", func() {
+			err := l.FatalfErr("retry %d failed", 3)
+			So(err, ShouldBeError, "retry 3 failed")
+			So(out.String(), ShouldEqual, "[][FATAL] retry 3 failed\n")
+			So(exited, ShouldBeFalse)
+		})
+	})
+}
+
+func TestSetPrefix(t *testing.T) {
+	Convey("Given logger with prefix", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app"})
+
+		Convey("It should return the current prefix", func() {
+			So(l.GetPrefix(), ShouldEqual, "app")
+		})
+
+		Convey("When the prefix replaced", func() {
+			So(l.SetPrefix("app-prod"), ShouldEqual, l)
+			l.Info("hello")
+
+			Convey("It should write the lines with the new prefix", func() {
+				So(l.GetPrefix(), ShouldEqual, "app-prod")
+				So(out.String(), ShouldEqual, "[app-prod][INFO]  hello\n")
+			})
+		})
+	})
+}
+
+func TestNamed(t *testing.T) {
+	Convey("Given logger with prefix", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "myapp"})
+
+		Convey("It should append the names after a dot", func() {
+			query := l.Named("db").Named("query")
+			query.Info("hello")
+			So(query.GetPrefix(), ShouldEqual, "myapp.db.query")
+			So(out.String(), ShouldEqual, "[myapp.db.query][INFO]  hello\n")
+		})
+
+		Convey("It should not change the parent", func() {
+			l.Named("db").WithDebug()
+			So(l.GetPrefix(), ShouldEqual, "myapp")
+			So(l.IsDebug(), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given logger without prefix", t, func() {
+		l := newLogger(Config{Out: &testWriter{}})
+
+		Convey("It should use the name as prefix", func() {
+			So(l.Named("db").GetPrefix(), ShouldEqual, "db")
+		})
+	})
+}
+
+func TestSetOutput(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var first, second testWriter
+		l := newLogger(Config{Out: &first}).WithoutCaller()
+
+		Convey("It should return the current output", func() {
+			So(l.GetOutput(), ShouldEqual, &first)
+		})
+
+		Convey("It should write to the new output after the change", func() {
+			l.Info("before")
+			So(l.SetOutput(&second), ShouldEqual, l)
+			l.Info("after")
+			So(l.GetOutput(), ShouldEqual, &second)
+			So(first.String(), ShouldEqual, "[][INFO]  before\n")
+			So(second.String(), ShouldEqual, "[][INFO]  after\n")
+		})
+
+		Convey("It should detect the color support of the new output", func() {
+			l.WithColor().Info("before")
+			So(l.WithOutput(&second), ShouldEqual, l)
+			l.Info("after")
+			So(l.IsColor(), ShouldBeFalse)
+			So(first.String(), ShouldContainSubstring, string(InfoPrefix.Color))
+			So(second.String(), ShouldEqual, "[][INFO]  after\n")
+		})
+	})
+}
+
+func TestFilter(t *testing.T) {
+	Convey("Given logger with filters", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller().
+			AddFilter(func(level Level, msg string) bool {
+				return !strings.Contains(msg, "noisy")
+			}).
+			AddFilter(func(level Level, msg string) bool {
+				return level >= LevelWarn || !strings.HasPrefix(msg, "poll")
+			})
+
+		Convey("It should write the message accepted by every filter", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should drop the message rejected by any filter", func() {
+			l.Info("a noisy line")
+			l.Info("poll done")
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should pass the level to the filters", func() {
+			l.Warn("poll failed")
+			So(out.String(), ShouldEqual, "[][WARN]  poll failed\n")
+		})
+
+		Convey("It should see the redacted message", func() {
+			l.WithRedaction(regexp.MustCompile("noisy")).Info("a noisy line")
+			So(out.String(), ShouldEqual, "[][INFO]  a [REDACTED] line\n")
+		})
+	})
+}
+
+func TestCallerFilter(t *testing.T) {
+	Convey("Given logger suppressing its own package", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithCallerForAll().
+			WithCallerFilter(SuppressPackages("github.com/other", "github.com/csturiale/go-log"))
+
+		Convey("It should drop the lines logged from the package", func() {
+			l.Info("hello")
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should always write the lines without caller info", func() {
+			l.WithoutCaller().Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should write every line once the filter removed", func() {
+			l.WithCallerFilter(nil).WithoutCaller().Info("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+
+	Convey("Given logger with caller filter", t, func() {
+		var file, fn string
+		l := newLogger(Config{Out: &testWriter{}}).WithCallerForAll().
+			WithCallerFilter(func(f, name string) bool {
+				file, fn = f, name
+				return true
+			})
+		l.Info("hello")
+
+		Convey("It should pass the caller file and function", func() {
+			So(file, ShouldEqual, "log_test.go")
+			So(fn, ShouldStartWith, "github.com/csturiale/go-log.TestCallerFilter")
+		})
+	})
+
+	Convey("Given package prefix filter", t, func() {
+		filter := SuppressPackages("example.com/app")
+
+		Convey("It should match the sub-packages but not the longer names", func() {
+			So(filter("a.go", "example.com/app/db.Open"), ShouldBeFalse)
+			So(filter("a.go", "example.com/application.Run"), ShouldBeTrue)
+		})
+	})
+}
+
+// slowWriter block the writes until released
+type slowWriter struct {
+	testWriter
+	release chan struct{}
+}
+
+// Write wait for the release before writing
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.testWriter.Write(p)
+}
+
+func TestTimeout(t *testing.T) {
+	Convey("Given logger with write timeout", t, func() {
+		out := &slowWriter{release: make(chan struct{})}
+		var reported error
+		l := newLogger(Config{Out: out, ErrorHandler: func(err error) { reported = err }}).
+			WithTimeout(10 * time.Millisecond)
+
+		Convey("It should abandon the slow write", func() {
+			So(l.Output(1, InfoPrefix, "hello"), ShouldResemble, context.DeadlineExceeded)
+			So(reported, ShouldResemble, context.DeadlineExceeded)
+
+			Convey("It should complete the write in the background", func() {
+				close(out.release)
+				So(l.Close(), ShouldBeNil)
+				So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+			})
+
+			Convey("It should fail right away while the abandoned write runs", func() {
+				So(l.Output(1, InfoPrefix, "dropped"), ShouldResemble, context.DeadlineExceeded)
+				close(out.release)
+				So(l.Close(), ShouldBeNil)
+				So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+			})
+		})
+
+		Convey("It should return once the write completes in time", func() {
+			close(out.release)
+			So(l.Output(1, InfoPrefix, "hello"), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+	})
+
+	Convey("Given logger with write timeout in front of a tee writer", t, func() {
+		var out, errs testWriter
+		l := newLogger(Config{Out: NewTeeWriter(&out, &errs, LevelError)}).WithoutCaller().
+			WithTimeout(time.Second)
+
+		Convey("It should pass the level of the lines to the output", func() {
+			l.Info("hello")
+			l.Error("failed")
+			So(l.Close(), ShouldBeNil)
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n[][ERROR] failed\n")
+			So(errs.String(), ShouldEqual, "[][ERROR] failed\n")
+		})
+	})
+}
+
+func TestPrefixes(t *testing.T) {
+	Convey("Given logger with custom prefixes", t, func() {
+		var out testWriter
+		p := DefaultPrefixes()
+		p.Warn = Prefix{Plain: []byte("[CAREFUL]"), Level: LevelError}
+		l := newLogger(Config{Out: &out}).WithPrefixes(p)
+		other := newLogger(Config{Out: &out})
+
+		Convey("It should use the custom prefix", func() {
+			l.Warn("hello")
+			So(out.String(), ShouldEqual, "[][CAREFUL] hello\n")
+		})
+
+		Convey("It should keep the level of the prefix position", func() {
+			So(l.prefix(LevelWarn).Level, ShouldEqual, LevelWarn)
+		})
+
+		Convey("It should not affect the other loggers", func() {
+			other.Warn("hello")
+			So(out.String(), ShouldEqual, "[][WARN]  hello\n")
+		})
+	})
+
+	Convey("Given the default prefixes", t, func() {
+		Convey("It should return a new copy every time", func() {
+			p := DefaultPrefixes()
+			p.Info.Plain[1] = 'X'
+			So(string(DefaultPrefixes().Info.Plain), ShouldEqual, "[INFO]")
+		})
+	})
+
+	Convey("Given prefix built with NewPrefixDef", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out})
+		tag := []byte("[AUDIT]")
+		audit := NewPrefixDef(tag, colorful.Cyan(tag), false)
+
+		Convey("It should write the custom tag with Output", func() {
+			l.Output(1, audit, "login")
+			So(out.String(), ShouldEqual, "[][AUDIT] login\n")
+		})
+
+		Convey("It should write the color tag when the color is on", func() {
+			l.WithColor().Output(1, audit, "login")
+			So(out.String(), ShouldEndWith, "[]"+string(colorful.Cyan(tag))+" login\n")
+		})
+
+		Convey("It should keep its own copy of the tags", func() {
+			tag[1] = 'X'
+			So(string(audit.Plain), ShouldEqual, "[AUDIT]")
+		})
+
+		Convey("It should reuse the plain tag without color", func() {
+			So(string(NewPrefixDef(tag, nil, false).Color), ShouldEqual, "[AUDIT]")
+		})
+
+		Convey("It should be filtered at info level", func() {
+			l.WithLevel(LevelWarn).Output(1, audit, "login")
+			So(out.String(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestRecover(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller()
+		explode := func() {
+			panic("boom")
+		}
+
+		Convey("It should log the panic with the stack and panic again", func() {
+			So(func() {
+				defer l.Recover()()
+				explode()
+			}, ShouldPanicWith, "boom")
+			So(out.String(), ShouldStartWith, "[][FATAL] panic: boom\n")
+			So(out.String(), ShouldContainSubstring, "log_test.go:")
+		})
+
+		Convey("It should not panic again with MustRecover", func() {
+			So(func() {
+				defer l.MustRecover()()
+				explode()
+			}, ShouldNotPanic)
+			So(out.String(), ShouldStartWith, "[][FATAL] panic: boom\n")
+		})
+
+		Convey("It should write nothing without panic", func() {
+			func() {
+				defer l.MustRecover()()
+			}()
+			So(out.String(), ShouldEqual, "")
+		})
+	})
+}
+
+func TestGetConfig(t *testing.T) {
+	Convey("Given logger", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app", Color: true, Level: LevelWarn})
+
+		Convey("It should return the current settings", func() {
+			So(l.IsColor(), ShouldBeTrue)
+			So(l.IsTimestamp(), ShouldBeFalse)
+			So(l.GetLevel(), ShouldEqual, LevelWarn)
+			So(l.GetConfig().Prefix, ShouldEqual, "app")
+		})
+
+		Convey("It should return the effective level in debug mode", func() {
+			l.WithDebug()
+			So(l.GetLevel(), ShouldEqual, LevelTrace)
+		})
+
+		Convey("It should not be affected by the later changes", func() {
+			config := l.GetConfig()
+			l.WithTimestamp().WithoutColor()
+			So(config.Timestamp, ShouldBeFalse)
+			So(config.Color, ShouldBeTrue)
+			So(l.IsTimestamp(), ShouldBeTrue)
+		})
+
+		Convey("It should not change the logger when the copy is changed", func() {
+			config := l.GetConfig()
+			config.Quiet = true
+			So(l.IsQuiet(), ShouldBeFalse)
+		})
+
+		Convey("It should copy the configuration without the writers", func() {
+			l.WithErrorsToStderr()
+			config := l.CopyConfig()
+			So(config.Out, ShouldBeNil)
+			So(config.writers, ShouldResemble, [numLevels]FdWriter{})
+			So(config.Prefix, ShouldEqual, "app")
+			So(l.GetOutput(), ShouldEqual, &out)
+		})
+	})
+}
+
+func TestDefaultLogger(t *testing.T) {
+	Convey("Given default logger", t, func() {
+		var out testWriter
+		defer SetDefault(logger.Load())
+		l := newLogger(Config{Out: &out}).WithLevel(LevelTrace)
+		SetDefault(l)
+
+		Convey("It should be returned by Default and Init", func() {
+			So(Default(), ShouldEqual, l)
+			got, err := Init(Config{Out: &testWriter{}})
+			So(err, ShouldBeNil)
+			So(got, ShouldEqual, l)
+		})
+
+		Convey("It should write the package level functions", func() {
+			Info("hello")
+			Warnf("disk %d%%", 90)
+			Trace("trace")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n[][WARN]  disk 90%\n[][TRACE] trace\n")
+		})
+
+		Convey("It should report the caller of the package level function", func() {
+			Error("failed")
+			Debugf("debug %d", 1)
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			So(lines, ShouldHaveLength, 2)
+			So(lines[0], ShouldStartWith, "[][ERROR] github.com/csturiale/go-log.TestDefaultLogger")
+			So(lines[0], ShouldContainSubstring, ":log_test.go:")
+			So(lines[1], ShouldContainSubstring, ":log_test.go:")
+		})
+
+		Convey("It should quit with status 1 on Fatal", func() {
+			code := 0
+			l.WithExitFunc(func(c int) { code = c })
+			Fatalf("fatal %s", "error")
+			So(code, ShouldEqual, 1)
+			So(out.String(), ShouldContainSubstring, " fatal error\n")
+		})
+	})
+
+	Convey("Given no default logger", t, func() {
+		defer SetDefault(logger.Load())
+		SetDefault(nil)
+
+		Convey("It should fall back to stderr", func() {
+			So(Default(), ShouldNotBeNil)
+			So(Default().config.Load().Out, ShouldEqual, os.Stderr)
+			So(Default(), ShouldEqual, Default())
+		})
+	})
+}
+
+func TestDetectColorSupport(t *testing.T) {
+	Convey("Given the NO_COLOR environment variable", t, func() {
+		t.Setenv("NO_COLOR", "1")
+
+		Convey("It should not detect color support", func() {
+			So(DetectColorSupport(os.Stdout.Fd()), ShouldBeFalse)
+		})
+
+		Convey("It should turn the color off on Init", func() {
+			defer logger.Store(nil)
+			logger.Store(nil)
+			l, err := Init(Config{Out: &testWriter{}, Color: true})
+			So(err, ShouldBeNil)
+			So(l.IsColor(), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given dumb terminal", t, func() {
+		t.Setenv("TERM", "dumb")
+
+		Convey("It should not detect color support", func() {
+			So(DetectColorSupport(os.Stderr.Fd()), ShouldBeFalse)
+		})
+
+		Convey("It should turn the color off on Init", func() {
+			defer logger.Store(logger.Load())
+			logger.Store(nil)
+			l, err := Init(Config{Out: &testWriter{}, Color: true})
+			So(err, ShouldBeNil)
+			So(l.IsColor(), ShouldBeFalse)
+			So(l.WithColor().IsColor(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given output which is not a standard stream", t, func() {
+		Convey("It should not detect color support", func() {
+			So(DetectColorSupport((&testWriter{}).Fd()), ShouldBeFalse)
+		})
+	})
+}
+
+func TestMultiLine(t *testing.T) {
+	Convey("Given logger and a multi-line message", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out, Prefix: "app"}).WithoutCaller()
+
+		Convey("It should write the continuation lines as they are by default", func() {
+			l.Info("first\nsecond")
+			So(out.String(), ShouldEqual, "[app][INFO]  first\nsecond\n")
+		})
+
+		Convey("It should indent the continuation lines under the message", func() {
+			l.WithMultiLine(MultiLineIndent).WithFields(Fields{"k": 1}).Info("first\nsecond")
+			So(out.String(), ShouldEqual, "[app][INFO]  first\n             second k=1\n")
+		})
+
+		Convey("It should repeat the line header on every line", func() {
+			l.WithMultiLine(MultiLinePrefix).Warn("first\nsecond\nthird")
+			So(out.String(), ShouldEqual, "[app][WARN]  first\n[app][WARN]  second\n[app][WARN]  third\n")
+		})
+
+		Convey("It should not count the color escapes in the indentation", func() {
+			l.WithColor().WithMultiLine(MultiLineIndent).Info("first\nsecond")
+			So(out.String(), ShouldEndWith, "first\n             second\n")
+		})
+	})
+}
+
+func TestJSONKeys(t *testing.T) {
+	Convey("Given JSON logger with custom keys", t, func() {
+		var out testWriter
+		now := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+		l := newLogger(Config{Out: &out, Clock: func() time.Time { return now }}).
+			WithFormat(FormatJSON).WithTimestamp().WithCaller().
+			WithJSONKeys(JSONKeys{Time: "@timestamp", Level: "severity", Message: "message"})
+		l.Info("hello")
+
+		Convey("It should rename the configured keys", func() {
+			var entry map[string]interface{}
+			So(json.Unmarshal(out.Bytes(), &entry), ShouldBeNil)
+			So(entry["@timestamp"], ShouldEqual, "2017-03-04T05:06:07Z")
+			So(entry["severity"], ShouldEqual, "INFO")
+			So(entry["message"], ShouldEqual, "hello")
+		})
+
+		Convey("It should keep the default name of the unset keys", func() {
+			So(out.String(), ShouldContainSubstring, `"caller":"log_test.go:`)
+			So(out.String(), ShouldContainSubstring, `"func":"`)
+			So(out.String(), ShouldNotContainSubstring, `"level"`)
+		})
+	})
+}
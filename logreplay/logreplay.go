@@ -0,0 +1,184 @@
+// Log replay for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package logreplay
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/csturiale/go-log"
+)
+
+// timeLayout is the layout of the text timestamp, the fraction of second is
+// accepted when parsing even if the layout does not have it
+const timeLayout = "2006/01/02 15:04:05"
+
+// maxLineSize is the longest line accepted by Replay
+const maxLineSize = 1024 * 1024
+
+// Level names of the full, short and letter level tags
+var levels = map[string]log.Level{
+	"FATAL": log.LevelFatal, "FTL": log.LevelFatal, "F": log.LevelFatal,
+	"ERROR": log.LevelError, "ERR": log.LevelError, "E": log.LevelError,
+	"WARN": log.LevelWarn, "WRN": log.LevelWarn, "W": log.LevelWarn,
+	"INFO": log.LevelInfo, "INF": log.LevelInfo, "I": log.LevelInfo,
+	"DEBUG": log.LevelDebug, "DBG": log.LevelDebug, "D": log.LevelDebug,
+	"TRACE": log.LevelTrace, "TRC": log.LevelTrace, "T": log.LevelTrace,
+}
+
+// Static fields written between the timestamp and the caller
+var staticFields = []string{"gid=", "host=", "pid=", "seq="}
+
+// callerPattern match the function:file:line caller info, the function is
+// missing with the CallerFile and CallerPath formats
+var callerPattern = regexp.MustCompile(`^(\S+:)?\S+\.go:\d+$`)
+
+// entry is a parsed text log line
+type entry struct {
+	name   string
+	level  log.Level
+	time   time.Time
+	fields []string
+	caller string
+	msg    string
+}
+
+// Replay parse the plain text log lines written by the logger from r and
+// write them again through l, e.g. to convert a saved log to JSON or to color
+// it. The original prefix, timestamp, static fields and caller are kept, the
+// caller as the caller field. The level tags are the prefixes of l, such as
+// the ones set with WithPrefixes or WithTheme. The lines which cannot be
+// parsed, such as the continuation of a multi-line message, are written
+// verbatim at warn level.
+func Replay(r io.Reader, l *log.Logger) error {
+	prefixes := l.GetPrefixes()
+	byLevel := map[log.Level]log.Prefix{
+		log.LevelFatal: prefixes.Fatal,
+		log.LevelError: prefixes.Error,
+		log.LevelWarn:  prefixes.Warn,
+		log.LevelInfo:  prefixes.Info,
+		log.LevelDebug: prefixes.Debug,
+		log.LevelTrace: prefixes.Trace,
+	}
+	// Write the parsed lines through a single copy of l taking the prefix and
+	// time of the current line, the lines without timestamp keep the clock
+	var e entry
+	clock := l.GetConfig().Clock
+	if clock == nil {
+		clock = time.Now
+	}
+	el := l.Clone().WithDynamicPrefix(func() string {
+		return e.name
+	}).WithClock(func() time.Time {
+		if e.time.IsZero() {
+			return clock()
+		}
+		return e.time
+	})
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	var fields []interface{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		var ok bool
+		if e, ok = parseLine(line); !ok {
+			if err := l.Output(1, byLevel[log.LevelWarn], line); err != nil {
+				return err
+			}
+			continue
+		}
+		fields = fields[:0]
+		for _, field := range e.fields {
+			key, value, _ := strings.Cut(field, "=")
+			fields = append(fields, key, value)
+		}
+		if e.caller != "" {
+			fields = append(fields, "caller", e.caller)
+		}
+		// The caller of the replayed line cannot be resolved again
+		prefix := byLevel[e.level]
+		prefix.File = false
+		if err := el.Outputw(1, prefix, e.msg, fields...); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parseLine split a [PREFIX][LEVEL] timestamp caller msg line, the
+// timestamp, static fields and caller are optional
+func parseLine(line string) (entry, bool) {
+	var e entry
+	name, rest, ok := cutTag(line)
+	if !ok {
+		return e, false
+	}
+	tag, rest, ok := cutTag(rest)
+	if !ok {
+		return e, false
+	}
+	if e.level, ok = levels[tag]; !ok {
+		return e, false
+	}
+	e.name = name
+	rest = strings.TrimLeft(rest, " ")
+	// Parse the date and time, the fraction of second is optional
+	if date, clock, tail, ok := cutWords(rest); ok {
+		if t, err := time.ParseInLocation(timeLayout, date+" "+clock, time.Local); err == nil {
+			e.time = t
+			rest = tail
+		}
+	}
+	for {
+		word, tail, _ := strings.Cut(rest, " ")
+		if !isStaticField(word) {
+			break
+		}
+		e.fields = append(e.fields, word)
+		rest = tail
+	}
+	if word, tail, _ := strings.Cut(rest, " "); callerPattern.MatchString(word) {
+		e.caller = word
+		rest = tail
+	}
+	e.msg = rest
+	return e, true
+}
+
+// cutTag returns the content of the bracketed tag at the start of s and the
+// text after it
+func cutTag(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return "", s, false
+	}
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1:end], s[end+1:], true
+}
+
+// cutWords returns the first two space separated words of s and the text
+// after them
+func cutWords(s string) (string, string, string, bool) {
+	first, rest, ok := strings.Cut(s, " ")
+	if !ok {
+		return "", "", s, false
+	}
+	second, rest, _ := strings.Cut(rest, " ")
+	return first, second, rest, true
+}
+
+// isStaticField check whether word is one of the static fields such as pid=1
+func isStaticField(word string) bool {
+	for _, prefix := range staticFields {
+		if strings.HasPrefix(word, prefix) && len(word) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
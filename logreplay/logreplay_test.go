@@ -0,0 +1,102 @@
+// Log replay for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package logreplay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/csturiale/go-log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// out is the output of the singleton logger returned by Init
+var out testWriter
+
+func TestReplay(t *testing.T) {
+	Convey("Given saved text log", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+		saved := strings.Join([]string{
+			"[app][INFO]  2017/03/04 05:06:07 pid=42 started",
+			"[app][ERROR] 2017/03/04 05:06:08.250 main.run:main.go:12 query failed",
+			"[][DEBUG] plain message",
+			"\tgoroutine trace",
+		}, "\n")
+
+		Convey("When replayed in JSON format", func() {
+			err := Replay(strings.NewReader(saved), l.Clone().WithFormat(log.FormatJSON).WithTimestamp())
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+
+			Convey("It should keep the prefix, time, fields and caller", func() {
+				So(err, ShouldBeNil)
+				So(lines, ShouldHaveLength, 3)
+				So(lines[0], ShouldEqual, `{"time":"`+localTime(2017, 3, 4, 5, 6, 7, 0)+`","level":"INFO","prefix":"app","msg":"started","pid":"42"}`)
+				So(lines[1], ShouldEqual, `{"time":"`+localTime(2017, 3, 4, 5, 6, 8, 0)+`","level":"ERROR","prefix":"app","msg":"query failed","caller":"main.run:main.go:12"}`)
+			})
+
+			Convey("It should write the invalid line verbatim at warn level", func() {
+				So(lines[2], ShouldContainSubstring, `"level":"WARN","msg":"\tgoroutine trace"`)
+			})
+		})
+
+		Convey("When the caller has no function", func() {
+			err := Replay(strings.NewReader("[app][WARN]  cmd/app/main.go:12 slow query"), l.Clone().WithFormat(log.FormatJSON))
+
+			Convey("It should keep the file and line as caller", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldEqual, `{"level":"WARN","prefix":"app","msg":"slow query","caller":"cmd/app/main.go:12"}`+"\n")
+			})
+		})
+
+		Convey("When replayed with the debug output", func() {
+			err := Replay(strings.NewReader(saved), l.Clone().WithDebug())
+
+			Convey("It should write the lines in text format again", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldContainSubstring, "[app][INFO]  started pid=42\n")
+				So(out.String(), ShouldContainSubstring, "[][DEBUG] plain message\n")
+				So(out.String(), ShouldContainSubstring, "[][WARN]  \tgoroutine trace\n")
+			})
+		})
+
+		Convey("When replayed with the prefixes of the logger", func() {
+			err := Replay(strings.NewReader(saved), l.Clone().WithDebug().WithPrefixes(log.LevelPrefixes{
+				Info:  log.NewPrefixDef([]byte("[I]"), nil, false),
+				Debug: log.NewPrefixDef([]byte("[D]"), nil, false),
+				Warn:  log.NewPrefixDef([]byte("[W]"), nil, false),
+			}))
+
+			Convey("It should write the level tags of the logger", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldEqual, strings.Join([]string{
+					"[app][I]     started pid=42",
+					"[app][ERROR] query failed caller=main.run:main.go:12",
+					"[][D]     plain message",
+					"[][W]     \tgoroutine trace",
+				}, "\n")+"\n")
+			})
+		})
+	})
+}
+
+// localTime returns the JSON timestamp of the date in the local time zone
+func localTime(year int, month time.Month, day, hour, min, sec, nsec int) string {
+	return time.Date(year, month, day, hour, min, sec, nsec, time.Local).Format("2006-01-02T15:04:05Z07:00")
+}
@@ -0,0 +1,119 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// HandlerFunc handle a single log line, the last handler of the chain write
+// it to the logger output
+type HandlerFunc func(entry LogEntry) error
+
+// Middleware wrap the next handler like a net/http middleware, it can change
+// the entry before calling next, e.g. to add a field or redact the message,
+// call next more than once, or drop the line by not calling it
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use append the middlewares to the chain every line goes through before it
+// is written, the first one is the outermost. The middlewares get the line
+// after the redaction and filters, with the prefix resolved and the fields of
+// the logger. The written line is built from the entry passed to the last
+// handler, its level, prefix, message, time, caller and fields.
+func (l *Logger) Use(mw ...Middleware) *Logger {
+	return l.update(func(c *Config) {
+		// Force a new backing array so the clones never share it
+		c.middlewares = append(c.middlewares[:len(c.middlewares):len(c.middlewares)], mw...)
+	})
+}
+
+// handle pass the record through the middlewares and write the entry reaching
+// the end of the chain, the entry callback gets the written entry. The prefix,
+// time, stack and fields of r are passed again so r does not escape to the
+// heap.
+func (l *Logger) handle(c *Config, r *record, now time.Time, prefix Prefix, stack []stackFrame, fields []Field, extra io.Writer) (bool, error) {
+	// The prefix is resolved before the middlewares so they see it, the prefix
+	// function is still called under the write lock
+	r.name = c.Prefix
+	if c.PrefixFunc != nil {
+		l.mu.Lock()
+		r.name = c.PrefixFunc()
+		l.mu.Unlock()
+	}
+	emitted := false
+	var lastConfig *Config
+	var last record
+	next := func(entry LogEntry) error {
+		ec, er := c.entryRecord(entry, prefix, stack, fields)
+		written := false
+		err := l.writeRecord(ec, &er, extra, &written)
+		if written {
+			emitted = true
+			lastConfig, last = ec, er
+		}
+		return err
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	err := next(newEntry(c, r, now))
+	if emitted && (c.entryCallback != nil || c.entries != nil) {
+		lastConfig.emitEntry(&last, last.now)
+	}
+	return emitted, err
+}
+
+// entryRecord returns the configuration and record to write the entry, the
+// level tag follow the entry level and the entry fields replace the fields of
+// the line, kept in the same order
+func (c *Config) entryRecord(entry LogEntry, prefix Prefix, stack []stackFrame, fields []Field) (*Config, record) {
+	ec := *c
+	ec.Prefix = entry.Prefix
+	ec.PrefixFunc = nil
+	written := make([]Field, 0, len(entry.Fields))
+	for _, field := range fields {
+		if value, ok := entry.Fields[field.Key]; ok {
+			written = append(written, Field{Key: field.Key, Value: value})
+		}
+	}
+	// The fields added by the middlewares are sorted after the line ones
+	added := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		if !hasField(written, key) {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		written = append(written, Field{Key: key, Value: entry.Fields[key]})
+	}
+	if entry.Level != prefix.Level && entry.Level.valid() {
+		file := prefix.File
+		prefix = c.prefixes[entry.Level.index()]
+		prefix.File = file
+	}
+	return &ec, record{
+		now:    entry.Timestamp,
+		prefix: prefix,
+		name:   entry.Prefix,
+		file:   entry.File,
+		line:   entry.Line,
+		fn:     entry.Func,
+		data:   []byte(entry.Message),
+		stack:  stack,
+		fields: written,
+	}
+}
+
+// hasField check whether the fields have one with the key
+func hasField(fields []Field, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// MultiLineMode define how the continuation lines of a multi-line message
+// are written in text format
+type MultiLineMode int
+
+// Available multi-line modes, the zero value is MultiLineRaw
+const (
+	// MultiLineRaw write the continuation lines as they are
+	MultiLineRaw MultiLineMode = iota
+	// MultiLineIndent indent the continuation lines under the message column
+	MultiLineIndent
+	// MultiLinePrefix repeat the prefix, timestamp and caller of the first
+	// line on every continuation line
+	MultiLinePrefix
+)
+
+// WithMultiLine set how the continuation lines of a multi-line message are
+// written in text format
+func (l *Logger) WithMultiLine(mode MultiLineMode) *Logger {
+	return l.update(func(c *Config) {
+		c.MultiLine = mode
+	})
+}
+
+// appendMessage write the message, the line header written since start is
+// repeated or indented on the continuation lines according to mode. The
+// trailing newline of the message is not a continuation line.
+func appendMessage(buf *colorful.ColorBuffer, mode MultiLineMode, start int, data []byte) {
+	if mode == MultiLineRaw {
+		buf.Append(data)
+		return
+	}
+	head := len(buf.Buffer)
+	width := -1
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 || i == len(data)-1 {
+			buf.Append(data)
+			return
+		}
+		buf.Append(data[:i+1])
+		data = data[i+1:]
+		if mode == MultiLinePrefix {
+			buf.Buffer = append(buf.Buffer, buf.Buffer[start:head]...)
+			continue
+		}
+		if width < 0 {
+			width = visibleWidth(buf.Buffer[start:head])
+		}
+		for n := 0; n < width; n++ {
+			buf.AppendByte(' ')
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Network writer for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package netlog
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/csturiale/go-log"
+)
+
+// DefaultBufferSize is the number of bytes kept while a TCP connection is down
+const DefaultBufferSize = 64 * 1024
+
+// Errors returned by Write
+var (
+	// ErrTooLarge is returned for a line larger than the TCP buffer size,
+	// which is dropped
+	ErrTooLarge = errors.New("netlog: line larger than the buffer size")
+	// ErrNotConnected is returned while the TCP connection is down, the line
+	// is kept in the buffer and sent once reconnected
+	ErrNotConnected = errors.New("netlog: not connected, line buffered")
+)
+
+// Reconnect backoff boundary
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Writer forward the log to a remote TCP or UDP endpoint
+type Writer struct {
+	mu      sync.Mutex
+	network string
+	addr    string
+	timeout time.Duration
+	size    int
+	conn    net.Conn
+	buf     []byte
+	backoff time.Duration
+	retry   time.Time
+}
+
+// NewNetworkWriter connect to addr and returns FdWriter forwarding every log
+// line to it. See NewNetworkWriterSize for the behavior.
+func NewNetworkWriter(network, addr string, timeout time.Duration) (log.FdWriter, error) {
+	return NewNetworkWriterSize(network, addr, timeout, DefaultBufferSize)
+}
+
+// NewNetworkWriterSize connect to addr and returns FdWriter forwarding every
+// log line to it. UDP send each line as a datagram and returns the send
+// error. A failed TCP write close the connection, keep up to size bytes of
+// the most recent data and reconnect with exponential backoff on the
+// following writes, a line larger than size is dropped with ErrTooLarge. The
+// timeout is applied to dial and each write, zero means no timeout.
+func NewNetworkWriterSize(network, addr string, timeout time.Duration, size int) (log.FdWriter, error) {
+	w := &Writer{
+		network: network,
+		addr:    addr,
+		timeout: timeout,
+		size:    size,
+	}
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write send the buffered data and p to the remote endpoint, it returns the
+// number of bytes of p sent. The part of p not sent over TCP is kept in the
+// buffer and sent on the next write.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.isUDP() {
+		if w.conn == nil {
+			return 0, net.ErrClosed
+		}
+		w.setDeadline()
+		return w.conn.Write(p)
+	}
+	if len(p) > w.size {
+		return 0, ErrTooLarge
+	}
+	w.buffer(p)
+	err := w.flush()
+	n := len(p) - len(w.buf)
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+// Fd returns invalid file descriptor since the output is never a terminal
+func (w *Writer) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// Close try to send the buffered data and close the connection
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.flush()
+	if w.conn != nil {
+		if cerr := w.conn.Close(); err == nil {
+			err = cerr
+		}
+		w.conn = nil
+	}
+	return err
+}
+
+// isUDP check for datagram network
+func (w *Writer) isUDP() bool {
+	return w.network == "udp" || w.network == "udp4" || w.network == "udp6"
+}
+
+// dial open the connection to the remote endpoint
+func (w *Writer) dial() error {
+	conn, err := net.DialTimeout(w.network, w.addr, w.timeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// setDeadline apply the write timeout if configured
+func (w *Writer) setDeadline() {
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+}
+
+// buffer append p and drop the oldest lines when size is exceeded, p must
+// not be larger than size
+func (w *Writer) buffer(p []byte) {
+	w.buf = append(w.buf, p...)
+	if over := len(w.buf) - w.size; over > 0 {
+		// Drop up to the end of the line to avoid sending partial line
+		if i := bytes.IndexByte(w.buf[over-1:], '\n'); i >= 0 {
+			over += i
+		}
+		w.buf = append(w.buf[:0], w.buf[over:]...)
+	}
+}
+
+// flush send the buffered data, reconnecting when the backoff has elapsed
+func (w *Writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if w.conn == nil {
+		if time.Now().Before(w.retry) {
+			return ErrNotConnected
+		}
+		if err := w.dial(); err != nil {
+			w.fail()
+			return err
+		}
+	}
+	w.setDeadline()
+	n, err := w.conn.Write(w.buf)
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.fail()
+		return err
+	}
+	w.backoff = 0
+	return nil
+}
+
+// fail schedule the next reconnect with exponential backoff
+func (w *Writer) fail() {
+	if w.backoff == 0 {
+		w.backoff = minBackoff
+	} else if w.backoff *= 2; w.backoff > maxBackoff {
+		w.backoff = maxBackoff
+	}
+	w.retry = time.Now().Add(w.backoff)
+}
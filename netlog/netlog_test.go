@@ -0,0 +1,122 @@
+// Network writer for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package netlog
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTCPWriter(t *testing.T) {
+	Convey("Given TCP listener and network writer", t, func() {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer ln.Close()
+		w, err := NewNetworkWriter("tcp", ln.Addr().String(), time.Second)
+		So(err, ShouldBeNil)
+		conn, err := ln.Accept()
+		So(err, ShouldBeNil)
+		defer conn.Close()
+
+		Convey("When a line written", func() {
+			_, err := w.Write([]byte("hello\n"))
+			So(err, ShouldBeNil)
+
+			Convey("It should be received by the listener", func() {
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				So(err, ShouldBeNil)
+				So(line, ShouldEqual, "hello\n")
+			})
+		})
+
+		Convey("It should return invalid file descriptor", func() {
+			So(w.Fd(), ShouldEqual, ^uintptr(0))
+		})
+
+		Convey("It should reject the line larger than the buffer", func() {
+			n, err := w.Write(make([]byte, DefaultBufferSize+1))
+			So(n, ShouldEqual, 0)
+			So(err, ShouldEqual, ErrTooLarge)
+		})
+
+		Reset(func() {
+			w.(*Writer).Close()
+		})
+	})
+
+	Convey("Given TCP writer waiting to reconnect", t, func() {
+		w := &Writer{network: "tcp", size: 64, retry: time.Now().Add(time.Hour)}
+
+		Convey("It should keep the line and report it is not sent", func() {
+			n, err := w.Write([]byte("hello\n"))
+			So(n, ShouldEqual, 0)
+			So(err, ShouldEqual, ErrNotConnected)
+			So(string(w.buf), ShouldEqual, "hello\n")
+		})
+	})
+}
+
+func TestUDPWriter(t *testing.T) {
+	Convey("Given UDP listener and network writer", t, func() {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer pc.Close()
+		w, err := NewNetworkWriter("udp", pc.LocalAddr().String(), time.Second)
+		So(err, ShouldBeNil)
+		defer w.(*Writer).Close()
+
+		Convey("It should send the line as a datagram", func() {
+			n, err := w.Write([]byte("hello\n"))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 6)
+			buf := make([]byte, 64)
+			n, _, err = pc.ReadFrom(buf)
+			So(err, ShouldBeNil)
+			So(string(buf[:n]), ShouldEqual, "hello\n")
+		})
+
+		Convey("It should return the send error of an oversized datagram", func() {
+			n, err := w.Write(make([]byte, 70000))
+			So(err, ShouldNotBeNil)
+			So(n, ShouldEqual, 0)
+		})
+
+		Convey("It should fail to write once closed", func() {
+			w.(*Writer).Close()
+			_, err := w.Write([]byte("hello\n"))
+			So(errors.Is(err, net.ErrClosed), ShouldBeTrue)
+		})
+	})
+}
+
+func TestBufferLimit(t *testing.T) {
+	Convey("Given writer with small buffer", t, func() {
+		w := &Writer{network: "tcp", size: 8}
+
+		Convey("When more lines than the limit buffered", func() {
+			w.buffer([]byte("first\n"))
+			w.buffer([]byte("second\n"))
+
+			Convey("It should drop the oldest whole line", func() {
+				So(string(w.buf), ShouldEqual, "second\n")
+			})
+		})
+
+		Convey("When the new line fill the buffer", func() {
+			w.buffer([]byte("first\n"))
+			w.buffer([]byte("second!\n"))
+
+			Convey("It should keep the new line", func() {
+				So(string(w.buf), ShouldEqual, "second!\n")
+			})
+		})
+	})
+}
@@ -0,0 +1,17 @@
+module github.com/csturiale/go-log/otellog
+
+go 1.21
+
+require (
+	github.com/csturiale/go-log v0.0.0
+	github.com/smartystreets/goconvey v1.8.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v1.13.1 // indirect
+	go.opentelemetry.io/otel v1.28.0 // indirect
+)
+
+replace github.com/csturiale/go-log => ../
@@ -0,0 +1,26 @@
+// OpenTelemetry integration for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+// Package otellog attach the OpenTelemetry trace context to the log lines. It
+// is a separate module so the core library stays dependency free.
+package otellog
+
+import (
+	"context"
+
+	log "github.com/csturiale/go-log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithOtel returns a child of l with the trace_id and span_id fields of the
+// span active in ctx, or l itself when ctx has no valid span
+func WithOtel(ctx context.Context, l *log.Logger) *log.Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return l.WithFields(log.Fields{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	})
+}
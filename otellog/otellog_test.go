@@ -0,0 +1,56 @@
+// OpenTelemetry integration for the go-log library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package otellog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	log "github.com/csturiale/go-log"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testWriter wrap bytes.Buffer to satisfy FdWriter
+type testWriter struct {
+	bytes.Buffer
+}
+
+// Fd returns invalid file descriptor
+func (w *testWriter) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// out is the output of the singleton logger returned by Init
+var out testWriter
+
+func TestWithOtel(t *testing.T) {
+	Convey("Given logger", t, func() {
+		out.Reset()
+		l, err := log.Init(log.Config{Out: &out})
+		So(err, ShouldBeNil)
+
+		Convey("When logging within a span", func() {
+			sc := trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{0x01, 0x02},
+				SpanID:  trace.SpanID{0x03},
+			})
+			ctx := trace.ContextWithSpanContext(context.Background(), sc)
+			WithOtel(ctx, l).Info("hello")
+
+			Convey("It should attach the trace and span IDs", func() {
+				So(out.String(), ShouldEqual, "[][INFO]  hello span_id=0300000000000000 trace_id=01020000000000000000000000000000\n")
+			})
+		})
+
+		Convey("When logging without a span", func() {
+			Convey("It should return the logger itself", func() {
+				So(WithOtel(context.Background(), l), ShouldEqual, l)
+			})
+		})
+	})
+}
@@ -0,0 +1,36 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"sync"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// maxPooledBuffer is the capacity above which a buffer is dropped instead of
+// being returned to the pool, so a single huge line does not pin memory
+const maxPooledBuffer = 64 << 10
+
+// bufferPool keep the line buffers shared by every logger
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return colorful.NewColorBuffer(256)
+	},
+}
+
+// getBuffer returns an empty line buffer from the pool
+func getBuffer() *colorful.ColorBuffer {
+	buf := bufferPool.Get().(*colorful.ColorBuffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer return the line buffer to the pool
+func putBuffer(buf *colorful.ColorBuffer) {
+	if cap(buf.Buffer) > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
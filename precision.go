@@ -0,0 +1,133 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"time"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// TimePrecision define the fraction of second written in the timestamp
+type TimePrecision int
+
+// Available timestamp precisions, the zero value is PrecisionSeconds
+const (
+	PrecisionSeconds TimePrecision = iota
+	PrecisionMillis
+	PrecisionMicros
+	PrecisionNanos
+)
+
+// JSON timestamp layouts indexed by precision
+var jsonTimeLayouts = [...]string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z07:00",
+	"2006-01-02T15:04:05.000000Z07:00",
+	"2006-01-02T15:04:05.000000000Z07:00",
+}
+
+// WithTimePrecision set the fraction of second written in the timestamp of
+// the text and JSON format
+func (l *Logger) WithTimePrecision(precision TimePrecision) *Logger {
+	return l.update(func(c *Config) {
+		c.TimePrecision = precision
+	})
+}
+
+// digits returns the number of fractional digits of the precision
+func (p TimePrecision) digits() int {
+	switch p {
+	case PrecisionMillis:
+		return 3
+	case PrecisionMicros:
+		return 6
+	case PrecisionNanos:
+		return 9
+	}
+	return 0
+}
+
+// jsonLayout returns the RFC3339 layout with the precision fraction
+func (p TimePrecision) jsonLayout() string {
+	if p < 0 || int(p) >= len(jsonTimeLayouts) {
+		return time.RFC3339
+	}
+	return jsonTimeLayouts[p]
+}
+
+// appendFraction write the zero padded fraction of second of now, such as
+// .007 for milliseconds precision
+func appendFraction(buf *colorful.ColorBuffer, now time.Time, precision TimePrecision) {
+	digits := precision.digits()
+	if digits == 0 {
+		return
+	}
+	frac := now.Nanosecond()
+	for i := digits; i < 9; i++ {
+		frac /= 10
+	}
+	buf.AppendByte('.')
+	buf.AppendInt(frac, digits)
+}
+
+// WithElapsed turn on the timestamp as the time elapsed since now, such as
+// +00:01.234, handy to profile the startup of an application
+func (l *Logger) WithElapsed() *Logger {
+	return l.update(func(c *Config) {
+		c.Timestamp = true
+		c.ElapsedSince = c.now()
+	})
+}
+
+// appendElapsed write the elapsed time as +[h:]mm:ss.fff, the fraction has
+// at least millisecond precision
+func appendElapsed(buf *colorful.ColorBuffer, d time.Duration, precision TimePrecision) {
+	buf.AppendByte('+')
+	if d < 0 {
+		d = 0
+	}
+	if d >= time.Hour {
+		buf.AppendInt(int(d/time.Hour), 0)
+		buf.AppendByte(':')
+	}
+	buf.AppendInt(int(d/time.Minute%60), 2)
+	buf.AppendByte(':')
+	buf.AppendInt(int(d/time.Second%60), 2)
+	digits := precision.digits()
+	if digits < 3 {
+		digits = 3
+	}
+	frac := int(d % time.Second)
+	for i := digits; i < 9; i++ {
+		frac /= 10
+	}
+	buf.AppendByte('.')
+	buf.AppendInt(frac, digits)
+}
+
+// WithLocation write the timestamp in the time zone loc whatever the host
+// time zone, such as time.UTC or a location loaded with time.LoadLocation. A
+// nil loc restore the time zone of the clock.
+func (l *Logger) WithLocation(loc *time.Location) *Logger {
+	return l.update(func(c *Config) {
+		c.Location = loc
+	})
+}
+
+// WithClock take the time of the log lines from clk instead of time.Now, a
+// nil clk restore time.Now
+func (l *Logger) WithClock(clk func() time.Time) *Logger {
+	return l.update(func(c *Config) {
+		c.Clock = clk
+	})
+}
+
+// WithFrozenClock write every log line with the time t, e.g. to assert on the
+// exact timestamp in tests
+func (l *Logger) WithFrozenClock(t time.Time) *Logger {
+	return l.WithClock(func() time.Time {
+		return t
+	})
+}
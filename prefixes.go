@@ -0,0 +1,91 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "github.com/csturiale/go-log/colorful"
+
+// LevelPrefixes hold the prefix of every level, bound to a logger with
+// WithPrefixes
+type LevelPrefixes struct {
+	Fatal Prefix
+	Error Prefix
+	Warn  Prefix
+	Info  Prefix
+	Debug Prefix
+	Trace Prefix
+}
+
+// DefaultPrefixes returns a new copy of the built-in level prefixes, it is
+// not affected by the changes of the deprecated package level prefixes
+func DefaultPrefixes() LevelPrefixes {
+	return LevelPrefixes{
+		Fatal: newPrefix("[FATAL]", colorful.Red, true, LevelFatal),
+		Error: newPrefix("[ERROR]", colorful.Red, true, LevelError),
+		Warn:  newPrefix("[WARN]", colorful.Orange, false, LevelWarn),
+		Info:  newPrefix("[INFO]", colorful.Green, false, LevelInfo),
+		Debug: newPrefix("[DEBUG]", colorful.Purple, true, LevelDebug),
+		Trace: newPrefix("[TRACE]", colorful.Cyan, false, LevelTrace),
+	}
+}
+
+// NewPrefixDef returns a prefix for Output with its own copy of the plain and
+// colored tags, e.g. to log at a custom level such as [AUDIT]. A nil color
+// reuse the plain tag. The prefix is at LevelInfo, set its Level to filter it
+// like another level. It is stable API like Output.
+func NewPrefixDef(plain, color []byte, file bool) Prefix {
+	if color == nil {
+		color = plain
+	}
+	return Prefix{
+		Plain: append([]byte(nil), plain...),
+		Color: append([]byte(nil), color...),
+		File:  file,
+	}
+}
+
+// newPrefix returns the prefix of the level with its own copy of the tag
+func newPrefix(tag string, paint func([]byte) []byte, file bool, level Level) Prefix {
+	plain := []byte(tag)
+	return Prefix{
+		Plain: plain,
+		Color: paint(plain),
+		File:  file,
+		Level: level,
+	}
+}
+
+// GetPrefixes returns the set of level prefixes bound to the logger, such as
+// the prefixes changed by WithPrefixes or WithTheme
+func (l *Logger) GetPrefixes() LevelPrefixes {
+	p := l.config.Load().prefixes
+	return LevelPrefixes{
+		Fatal: p[LevelFatal.index()],
+		Error: p[LevelError.index()],
+		Warn:  p[LevelWarn.index()],
+		Info:  p[LevelInfo.index()],
+		Debug: p[LevelDebug.index()],
+		Trace: p[LevelTrace.index()],
+	}
+}
+
+// levels returns the prefixes indexed by level
+func (p LevelPrefixes) levels() [numLevels]Prefix {
+	return [numLevels]Prefix{p.Trace, p.Debug, p.Info, p.Warn, p.Error, p.Fatal}
+}
+
+// WithPrefixes bind the set of level prefixes to the logger, the prefixes
+// without plain tag keep the current one. Unlike changing the package level
+// prefixes it never affect the other loggers.
+func (l *Logger) WithPrefixes(p LevelPrefixes) *Logger {
+	return l.update(func(c *Config) {
+		for i, prefix := range p.levels() {
+			if prefix.Plain == nil {
+				continue
+			}
+			// The level is implied by the position in the set
+			prefix.Level = c.prefixes[i].Level
+			c.prefixes[i] = prefix
+		}
+	})
+}
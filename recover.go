@@ -0,0 +1,41 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "fmt"
+
+// recoverStackDepth is the maximum number of frames of the panic stack
+const recoverStackDepth = 256
+
+// Recover returns a function to be deferred which log the panic at fatal
+// level with the stack of the panicking goroutine and panic again with the
+// same value, the program does not exit through ExitFunc
+//
+//	defer logger.Recover()()
+func (l *Logger) Recover() func() {
+	return func() {
+		if r := recover(); r != nil {
+			l.logPanic(r)
+			panic(r)
+		}
+	}
+}
+
+// MustRecover returns a function to be deferred which log the panic like
+// Recover but does not panic again, the deferring function returns normally
+func (l *Logger) MustRecover() func() {
+	return func() {
+		if r := recover(); r != nil {
+			l.logPanic(r)
+		}
+	}
+}
+
+// logPanic write the panic value with the stack and flush the output, it is
+// called by the deferred function
+func (l *Logger) logPanic(r interface{}) {
+	c := l.child().WithAutoStack(recoverStackDepth)
+	c.Output(3, c.prefix(LevelFatal), fmt.Sprint("panic: ", r))
+	l.flush()
+}
@@ -0,0 +1,58 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "regexp"
+
+// redacted replace the text matching a redaction pattern
+var redacted = []byte("[REDACTED]")
+
+// WithRedaction replace the parts of the message and of the field values
+// matching any of the patterns with [REDACTED], before the line is formatted
+func (l *Logger) WithRedaction(patterns ...*regexp.Regexp) *Logger {
+	return l.addRedactor(func(data []byte) []byte {
+		for _, pattern := range patterns {
+			data = pattern.ReplaceAll(data, redacted)
+		}
+		return data
+	})
+}
+
+// WithRedactionFunc pass the message and the field values through fn before
+// the line is formatted, e.g. to mask all but the last digits of a card number
+func (l *Logger) WithRedactionFunc(fn func(string) string) *Logger {
+	return l.addRedactor(func(data []byte) []byte {
+		return []byte(fn(string(data)))
+	})
+}
+
+// addRedactor append the redactor after the existing ones
+func (l *Logger) addRedactor(fn func([]byte) []byte) *Logger {
+	return l.update(func(c *Config) {
+		// Force a new backing array so the clones never share it
+		c.redactors = append(c.redactors[:len(c.redactors):len(c.redactors)], fn)
+	})
+}
+
+// redact returns a copy of the message passed through every redactor, the
+// copy keeps the message of the caller from escaping to the heap
+func (c *Config) redact(data []byte) []byte {
+	masked := append([]byte(nil), data...)
+	for _, fn := range c.redactors {
+		masked = fn(masked)
+	}
+	return masked
+}
+
+// redactValue returns the string form of the field value passed through every
+// redactor when they changed it, the value as is otherwise so the numbers stay
+// numbers in JSON
+func (c *Config) redactValue(v interface{}) interface{} {
+	s := valueString(v)
+	masked := string(c.redact([]byte(s)))
+	if masked == s {
+		return v
+	}
+	return masked
+}
@@ -0,0 +1,67 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxRingLineLen is the maximum stored length of a single ring buffer line
+const maxRingLineLen = 4096
+
+// RingBuffer is a FdWriter keeping the most recent log lines in memory, e.g.
+// to expose them on a debug endpoint
+type RingBuffer struct {
+	mu    sync.RWMutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewRingBuffer returns ring buffer keeping the last n lines, each line is
+// cut to 4096 bytes so the memory usage stay bounded
+func NewRingBuffer(n int) *RingBuffer {
+	if n < 1 {
+		n = 1
+	}
+	return &RingBuffer{
+		lines: make([]string, n),
+	}
+}
+
+// Write store every line of p
+func (rb *RingBuffer) Write(p []byte) (int, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimSuffix(p, []byte("\n")), []byte("\n")) {
+		if len(line) > maxRingLineLen {
+			line = line[:maxRingLineLen]
+		}
+		rb.lines[rb.next] = string(line)
+		rb.next++
+		if rb.next == len(rb.lines) {
+			rb.next = 0
+			rb.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// Fd returns invalid file descriptor since the buffer is never a terminal
+func (rb *RingBuffer) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// Lines returns a copy of the stored lines from the oldest to the newest
+func (rb *RingBuffer) Lines() []string {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	if !rb.full {
+		return append([]string(nil), rb.lines[:rb.next]...)
+	}
+	lines := make([]string, 0, len(rb.lines))
+	lines = append(lines, rb.lines[rb.next:]...)
+	return append(lines, rb.lines[:rb.next]...)
+}
@@ -0,0 +1,35 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package log
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRingBuffer(t *testing.T) {
+	Convey("Given ring buffer of two lines", t, func() {
+		rb := NewRingBuffer(2)
+
+		Convey("When less lines written", func() {
+			rb.Write([]byte("first\n"))
+
+			Convey("It should return the written lines", func() {
+				So(rb.Lines(), ShouldResemble, []string{"first"})
+			})
+		})
+
+		Convey("When more lines written", func() {
+			rb.Write([]byte("first\n"))
+			rb.Write([]byte("second\nthird\n"))
+
+			Convey("It should only keep the most recent lines in order", func() {
+				So(rb.Lines(), ShouldResemble, []string{"second", "third"})
+			})
+		})
+	})
+}
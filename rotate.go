@@ -0,0 +1,177 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// RotatingFileWriter is a FdWriter that rotate the log file when it grows
+// beyond the maximum size, keeping the older files as path.1, path.2, etc.
+type RotatingFileWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFileWriter open or create the log file at path which is rotated
+// once it exceed maxSize bytes (zero means only manual rotation), keeping at
+// most maxBackups older files
+func NewRotatingFileWriter(path string, maxSize int64, maxBackups int) (*RotatingFileWriter, error) {
+	w := &RotatingFileWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write append p to the file and rotate it first when p does not fit. When
+// the rotation fails p is still written to the current file and the rotation
+// error is returned.
+func (w *RotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	var rerr error
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		rerr = w.rotate()
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rerr
+	}
+	return n, err
+}
+
+// Fd returns the file descriptor of the current file
+func (w *RotatingFileWriter) Fd() uintptr {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return ^uintptr(0)
+	}
+	return w.file.Fd()
+}
+
+// RotateNow rotate the file regardless of its size, e.g. on a schedule
+func (w *RotatingFileWriter) RotateNow() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Sync commit the current file to stable storage
+func (w *RotatingFileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Close close the current file
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open the log file for append and read its current size
+func (w *RotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = stat.Size()
+	return nil
+}
+
+// rotate shift the backup files, move the current file to path.1 and reopen
+// it, caller must hold the lock. The current file is kept open until the new
+// one is opened, so the writer keep working when the rotation fails.
+func (w *RotatingFileWriter) rotate() error {
+	if w.maxBackups > 0 {
+		if err := os.Remove(w.backup(w.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		for i := w.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(w.backup(i), w.backup(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.Rename(w.path, w.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return err
+	}
+	file := w.file
+	if err := w.open(); err != nil {
+		// Move the file back and keep writing to it
+		if w.maxBackups > 0 {
+			os.Rename(w.backup(1), w.path)
+		}
+		return err
+	}
+	return file.Close()
+}
+
+// backup returns the path of the nth backup file
+func (w *RotatingFileWriter) backup(n int) string {
+	return w.path + "." + strconv.Itoa(n)
+}
+
+// ErrRotationUnsupported is returned by Rotate when the output does not
+// support manual rotation
+var ErrRotationUnsupported = errors.New("log: output does not support rotation")
+
+// rotator is an output supporting manual rotation such as RotatingFileWriter
+type rotator interface {
+	RotateNow() error
+}
+
+// Rotate rotate the logger output if it support manual rotation such as
+// RotatingFileWriter, also through the write buffer, asynchronous and timeout
+// writers which write their pending lines to the current file first
+func (l *Logger) Rotate() error {
+	return rotateOutput(l.config.Load().Out)
+}
+
+// rotateOutput rotate out if it support manual rotation
+func rotateOutput(out FdWriter) error {
+	r, ok := out.(rotator)
+	if !ok {
+		return ErrRotationUnsupported
+	}
+	return r.RotateNow()
+}
@@ -0,0 +1,97 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	Convey("Given logger writing to rotating file", t, func() {
+		path := filepath.Join(t.TempDir(), "app.log")
+		w, err := NewRotatingFileWriter(path, 30, 2)
+		So(err, ShouldBeNil)
+		defer w.Close()
+		l := newLogger(Config{Out: w})
+
+		Convey("When the file grows beyond the maximum size", func() {
+			l.Info("first message")
+			l.Info("second message")
+
+			Convey("It should move the older line to the backup file", func() {
+				backup, err := os.ReadFile(path + ".1")
+				So(err, ShouldBeNil)
+				So(string(backup), ShouldEqual, "[][INFO]  first message\n")
+				current, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(string(current), ShouldEqual, "[][INFO]  second message\n")
+			})
+		})
+
+		Convey("When rotated manually", func() {
+			l.Info("first")
+			So(l.Rotate(), ShouldBeNil)
+			So(l.Rotate(), ShouldBeNil)
+			So(l.Rotate(), ShouldBeNil)
+
+			Convey("It should keep at most the configured backups", func() {
+				_, err := os.Stat(path + ".2")
+				So(err, ShouldBeNil)
+				_, err = os.Stat(path + ".3")
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When rotated through the output wrappers", func() {
+			for _, wrapped := range []*Logger{
+				l.Clone().WithWriteBuffer(4096, time.Hour),
+				l.Clone().WithAsync(16),
+				l.Clone().WithTimeout(time.Second),
+			} {
+				wrapped.Info("pending")
+				So(wrapped.Rotate(), ShouldBeNil)
+			}
+
+			Convey("It should write the pending lines before rotating", func() {
+				for _, backup := range []string{path + ".1", path + ".2"} {
+					data, err := os.ReadFile(backup)
+					So(err, ShouldBeNil)
+					So(string(data), ShouldEqual, "[][INFO]  pending\n")
+				}
+			})
+		})
+
+		Convey("When a backup file cannot be removed", func() {
+			So(os.MkdirAll(filepath.Join(path+".2", "busy"), 0755), ShouldBeNil)
+			l.Info("first")
+
+			Convey("It should keep writing to the current file", func() {
+				So(l.Rotate(), ShouldNotBeNil)
+				l.Info("second")
+				current, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(string(current), ShouldEqual, "[][INFO]  first\n[][INFO]  second\n")
+			})
+		})
+	})
+
+	Convey("Given logger writing to non rotating output", t, func() {
+		l := newLogger(Config{Out: &testWriter{}})
+
+		Convey("It should fail to rotate", func() {
+			So(l.Rotate(), ShouldEqual, ErrRotationUnsupported)
+		})
+
+		Convey("It should fail to rotate through the write buffer", func() {
+			So(l.WithWriteBuffer(4096, time.Hour).Rotate(), ShouldEqual, ErrRotationUnsupported)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Signal level toggle state
+var (
+	signalMu   sync.Mutex
+	signalCh   chan os.Signal
+	signalDone chan struct{}
+)
+
+// EnableSignalLevelToggle listen for up and down signal to make the logger
+// more or less verbose at runtime (e.g. Info -> Debug -> Trace on up). The
+// level is clamped at LevelTrace and LevelFatal. Calling it again replace the
+// previous listener.
+func EnableSignalLevelToggle(l *Logger, up os.Signal, down os.Signal) {
+	signalMu.Lock()
+	defer signalMu.Unlock()
+	stopSignalLevelToggle()
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, up, down)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				if sig == up {
+					l.shiftLevel(-1)
+				} else if sig == down {
+					l.shiftLevel(1)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	signalCh = ch
+	signalDone = done
+}
+
+// DisableSignalLevelToggle stop listening for the level toggle signal
+func DisableSignalLevelToggle() {
+	signalMu.Lock()
+	defer signalMu.Unlock()
+	stopSignalLevelToggle()
+}
+
+// stopSignalLevelToggle stop the running listener, caller must hold signalMu
+func stopSignalLevelToggle() {
+	if signalCh == nil {
+		return
+	}
+	signal.Stop(signalCh)
+	close(signalDone)
+	signalCh = nil
+	signalDone = nil
+}
@@ -0,0 +1,65 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// waitLevel poll the logger level until it is want or the deadline pass, the
+// signals are handled by another goroutine
+func waitLevel(l *Logger, want Level) Level {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if level := l.config.Load().level(); level == want {
+			return level
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return l.config.Load().level()
+}
+
+func TestSignalLevelToggle(t *testing.T) {
+	Convey("Given logger listening for the level toggle signals", t, func() {
+		// Keep the signals from killing the process once the toggle is stopped
+		guard := make(chan os.Signal, 4)
+		signal.Notify(guard, syscall.SIGUSR1, syscall.SIGUSR2)
+		defer signal.Stop(guard)
+		var out testWriter
+		l := newLogger(Config{Out: &out, Level: LevelInfo}).WithCallerForLevel(LevelDebug, false)
+		EnableSignalLevelToggle(l, syscall.SIGUSR1, syscall.SIGUSR2)
+		defer DisableSignalLevelToggle()
+
+		Convey("It should make the logger more verbose on up", func() {
+			syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+			So(waitLevel(l, LevelDebug), ShouldEqual, LevelDebug)
+			l.Debug("hello")
+			So(out.String(), ShouldEqual, "[][DEBUG] hello\n")
+		})
+
+		Convey("It should make the logger less verbose on down", func() {
+			syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+			So(waitLevel(l, LevelWarn), ShouldEqual, LevelWarn)
+			l.Info("hello")
+			So(out.Len(), ShouldEqual, 0)
+		})
+
+		Convey("It should keep the level once disabled", func() {
+			DisableSignalLevelToggle()
+			syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+			<-guard
+			So(l.config.Load().level(), ShouldEqual, LevelInfo)
+		})
+	})
+}
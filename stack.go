@@ -0,0 +1,83 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"runtime"
+
+	"github.com/csturiale/go-log/colorful"
+)
+
+// defaultStackDepth is the maximum number of captured frames when no depth
+// is configured
+const defaultStackDepth = 32
+
+// stackFrame is a single captured call frame
+type stackFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// WithAutoStack attach the stack of the caller to every Error and Fatal line
+// as the stack field, limited to depth frames (zero means 32 frames)
+func (l *Logger) WithAutoStack(depth int) *Logger {
+	return l.update(func(c *Config) {
+		c.AutoStackOnError = true
+		c.StackDepth = depth
+	})
+}
+
+// WithoutAutoStack turn off the automatic stack on Error and Fatal lines
+func (l *Logger) WithoutAutoStack() *Logger {
+	return l.update(func(c *Config) {
+		c.AutoStackOnError = false
+	})
+}
+
+// captureStack returns at most depth frames skipping skip frames above the
+// caller of captureStack
+func captureStack(skip int, depth int) []stackFrame {
+	if depth <= 0 {
+		depth = defaultStackDepth
+	}
+	pcs := make([]uintptr, depth)
+	n := runtime.Callers(skip+2, pcs)
+	if n == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]stackFrame, 0, n)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, stackFrame{
+			Func: frame.Function,
+			File: frame.File,
+			Line: frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// appendJSONStack write the frames as a JSON array of objects
+func appendJSONStack(buf *colorful.ColorBuffer, frames []stackFrame) {
+	buf.AppendByte('[')
+	for i, frame := range frames {
+		if i > 0 {
+			buf.AppendByte(',')
+		}
+		buf.AppendByte('{')
+		appendJSONKey(buf, "func")
+		appendJSONString(buf, frame.Func)
+		appendJSONKey(buf, "file")
+		appendJSONString(buf, frame.File)
+		appendJSONKey(buf, "line")
+		buf.AppendInt(frame.Line, 0)
+		buf.AppendByte('}')
+	}
+	buf.AppendByte(']')
+}
@@ -0,0 +1,77 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "fmt"
+
+// Errorw print error message with the alternating keys and values as fields
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.mayEnable(LevelError) {
+		l.outputw(1, LevelError, msg, keysAndValues)
+	}
+}
+
+// Warnw print warning message with the alternating keys and values as fields
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.mayEnable(LevelWarn) {
+		l.outputw(1, LevelWarn, msg, keysAndValues)
+	}
+}
+
+// Infow print informational message with the alternating keys and values as
+// fields
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if l.mayEnable(LevelInfo) {
+		l.outputw(1, LevelInfo, msg, keysAndValues)
+	}
+}
+
+// Debugw print Debug message with the alternating keys and values as fields
+// if Debug output enabled, the fields are not built otherwise
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.mayEnable(LevelDebug) {
+		l.outputw(1, LevelDebug, msg, keysAndValues)
+	}
+}
+
+// Tracew print trace message with the alternating keys and values as fields
+// if Debug output enabled, the fields are not built otherwise
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	if l.mayEnable(LevelTrace) {
+		l.outputw(1, LevelTrace, msg, keysAndValues)
+	}
+}
+
+// Outputw print msg at the prefix like Output with the alternating keys and
+// values as fields like Infow, the depth is counted the same way as Output
+func (l *Logger) Outputw(depth int, prefix Prefix, msg string, keysAndValues ...interface{}) error {
+	return l.output(depth+1, prefix, []byte(msg), pairFields(keysAndValues), nil)
+}
+
+// outputw write msg with the keys and values as fields
+func (l *Logger) outputw(depth int, level Level, msg string, keysAndValues []interface{}) error {
+	return l.output(depth+1, l.prefix(level), []byte(msg), pairFields(keysAndValues), nil)
+}
+
+// badKey is the key of the dangling last argument of an odd-length list
+const badKey = "!BADKEY"
+
+// pairFields returns the fields of alternating keys and values, a key which
+// is not a string is formatted with fmt.Sprint and the dangling last argument
+// of an odd-length list is written as the value of the !BADKEY field
+func pairFields(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields = append(fields, Field{Key: badKey, Value: keysAndValues[i]})
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keysAndValues[i+1]})
+	}
+	return fields
+}
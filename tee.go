@@ -0,0 +1,59 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "sync"
+
+// levelWriter is implemented by the outputs which need the level of the line
+// they write, the logger call WriteLevel instead of Write
+type levelWriter interface {
+	WriteLevel(level Level, p []byte) (int, error)
+}
+
+// TeeWriter write every line to a primary writer and the lines at or above a
+// minimum level to a secondary writer
+type TeeWriter struct {
+	mu        sync.Mutex
+	primary   FdWriter
+	secondary FdWriter
+	minLevel  Level
+}
+
+// NewTeeWriter returns a writer sending every line to primary and the lines
+// at or above minLevel to secondary as well, e.g. to copy the errors of a
+// log file to stderr. It is safe for concurrent use.
+func NewTeeWriter(primary, secondary FdWriter, minLevel Level) FdWriter {
+	return &TeeWriter{
+		primary:   primary,
+		secondary: secondary,
+		minLevel:  minLevel,
+	}
+}
+
+// Write write p to the primary writer only since its level is unknown
+func (w *TeeWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.primary.Write(p)
+}
+
+// WriteLevel write p to the primary writer, and to the secondary writer when
+// level is at or above the minimum level. The secondary writer is written
+// even if the primary fails, the first error is returned.
+func (w *TeeWriter) WriteLevel(level Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.primary.Write(p)
+	if level >= w.minLevel {
+		if _, serr := w.secondary.Write(p); err == nil {
+			err = serr
+		}
+	}
+	return n, err
+}
+
+// Fd returns the file descriptor of the primary writer
+func (w *TeeWriter) Fd() uintptr {
+	return w.primary.Fd()
+}
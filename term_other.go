@@ -0,0 +1,12 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package log
+
+// terminalWidth returns zero since the terminal size is not known on this
+// platform
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,27 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package log
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the terminal size returned by the TIOCGWINSZ ioctl
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// terminalWidth returns the number of columns of the terminal with the file
+// descriptor fd, or zero when it is not a terminal
+func terminalWidth(fd uintptr) int {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.cols)
+}
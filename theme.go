@@ -0,0 +1,86 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import "github.com/csturiale/go-log/colorful"
+
+// Theme hold the color of every level tag and of the timestamp and caller,
+// bound to a logger with WithTheme. An empty color keep the current one.
+type Theme struct {
+	Fatal     colorful.Color
+	Error     colorful.Color
+	Warn      colorful.Color
+	Info      colorful.Color
+	Debug     colorful.Color
+	Trace     colorful.Color
+	Timestamp colorful.Color
+	Caller    colorful.Color
+}
+
+// DefaultTheme returns the built-in colors, suited to a dark background
+func DefaultTheme() Theme {
+	return Theme{
+		Fatal:     colorful.ColorRed,
+		Error:     colorful.ColorRed,
+		Warn:      colorful.ColorOrange,
+		Info:      colorful.ColorGreen,
+		Debug:     colorful.ColorPurple,
+		Trace:     colorful.ColorCyan,
+		Timestamp: colorful.ColorBlue,
+		Caller:    colorful.ColorOrange,
+	}
+}
+
+// LightTheme returns darker 256 colors which stay readable on a light
+// background
+func LightTheme() Theme {
+	return Theme{
+		Fatal:     "\033[1;38;5;124m",
+		Error:     "\033[38;5;124m",
+		Warn:      "\033[38;5;130m",
+		Info:      "\033[38;5;28m",
+		Debug:     "\033[38;5;90m",
+		Trace:     "\033[38;5;24m",
+		Timestamp: "\033[38;5;19m",
+		Caller:    "\033[38;5;94m",
+	}
+}
+
+// HighContrastTheme returns bold bright colors for accessibility, the fatal
+// tag is written white on red
+func HighContrastTheme() Theme {
+	return Theme{
+		Fatal:     "\033[1;97;41m",
+		Error:     "\033[1;91m",
+		Warn:      "\033[1;93m",
+		Info:      "\033[1;92m",
+		Debug:     "\033[1;95m",
+		Trace:     "\033[1;96m",
+		Timestamp: "\033[1;94m",
+		Caller:    "\033[1;97m",
+	}
+}
+
+// levels returns the level colors indexed by level
+func (t Theme) levels() [numLevels]colorful.Color {
+	return [numLevels]colorful.Color{t.Trace, t.Debug, t.Info, t.Warn, t.Error, t.Fatal}
+}
+
+// WithTheme color the level tags, timestamp and caller of the logger with the
+// theme, the plain tags are kept
+func (l *Logger) WithTheme(t Theme) *Logger {
+	return l.update(func(c *Config) {
+		for i, color := range t.levels() {
+			if color != "" {
+				c.prefixes[i].Color = colorful.Paint(c.prefixes[i].Plain, color)
+			}
+		}
+		if t.Timestamp != "" {
+			c.timestampColor = []byte(t.Timestamp)
+		}
+		if t.Caller != "" {
+			c.callerColor = []byte(t.Caller)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// timeoutWriter abandon the writes to the output taking longer than timeout,
+// the lines are written by a single goroutine one at a time
+type timeoutWriter struct {
+	mu      sync.Mutex
+	out     FdWriter
+	timeout time.Duration
+	once    sync.Once
+	lines   chan timeoutLine
+	results chan timeoutResult
+	// line is the copy of the line being written, busy is set while it is
+	// written and closed once Close is called
+	line   []byte
+	busy   bool
+	closed bool
+}
+
+// timeoutLine is a line passed to the writer goroutine
+type timeoutLine struct {
+	level   Level
+	leveled bool
+	p       []byte
+}
+
+// timeoutResult is the result of the write of a line
+type timeoutResult struct {
+	n   int
+	err error
+}
+
+// WithTimeout cap the time spent writing a line to the current output, a
+// write taking longer than d is abandoned and Output returns
+// context.DeadlineExceeded, use the ErrorHandler to fall back to stderr. The
+// abandoned write complete in the background and the next lines fail right
+// away with context.DeadlineExceeded until it does.
+func (l *Logger) WithTimeout(d time.Duration) *Logger {
+	return l.update(func(c *Config) {
+		c.Out = &timeoutWriter{out: c.Out, timeout: d}
+	})
+}
+
+// Write write p to the output from the writer goroutine and wait until it
+// completes or the timeout expires
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	return w.write(timeoutLine{p: p})
+}
+
+// WriteLevel write p like Write, the level is passed to the output when it
+// need it such as TeeWriter
+func (w *timeoutWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return w.write(timeoutLine{level: level, leveled: true, p: p})
+}
+
+// write pass the line to the writer goroutine, it fails right away while an
+// abandoned write is still running
+func (w *timeoutWriter) write(line timeoutLine) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.busy {
+		select {
+		case <-w.results:
+			w.busy = false
+		default:
+			return 0, context.DeadlineExceeded
+		}
+	}
+	w.once.Do(func() {
+		w.lines = make(chan timeoutLine)
+		w.results = make(chan timeoutResult, 1)
+		go w.run()
+	})
+	// Copy the line as the caller reuse its buffer once abandoned
+	w.line = append(w.line[:0], line.p...)
+	line.p = w.line
+	w.busy = true
+	w.lines <- line
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-w.results:
+		w.busy = false
+		return r.n, r.err
+	case <-timer.C:
+		return 0, context.DeadlineExceeded
+	}
+}
+
+// run write the lines to the output until the writer is closed
+func (w *timeoutWriter) run() {
+	lw, ok := w.out.(levelWriter)
+	for line := range w.lines {
+		var r timeoutResult
+		if ok && line.leveled {
+			r.n, r.err = lw.WriteLevel(line.level, line.p)
+		} else {
+			r.n, r.err = w.out.Write(line.p)
+		}
+		w.results <- r
+	}
+}
+
+// wait wait for the abandoned write to complete, caller must hold the lock
+func (w *timeoutWriter) wait() {
+	if w.busy {
+		<-w.results
+		w.busy = false
+	}
+}
+
+// Fd returns the file descriptor of the output
+func (w *timeoutWriter) Fd() uintptr {
+	return w.out.Fd()
+}
+
+// Flush flush the output if it support flushing
+func (w *timeoutWriter) Flush() error {
+	if flusher, ok := w.out.(interface{ Flush() error }); ok {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.wait()
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// RotateNow wait for the abandoned write to complete and rotate the output if
+// it support manual rotation
+func (w *timeoutWriter) RotateNow() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wait()
+	return rotateOutput(w.out)
+}
+
+// Sync commit the output to stable storage if it support syncing
+func (w *timeoutWriter) Sync() error {
+	if s, ok := w.out.(syncer); ok {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.wait()
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close wait for the abandoned write to complete, stop the writer goroutine
+// and close the output if it implements io.Closer
+func (w *timeoutWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	w.closed = true
+	w.wait()
+	if w.lines != nil {
+		close(w.lines)
+	}
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
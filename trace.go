@@ -0,0 +1,80 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"net/http"
+	"strings"
+)
+
+// traceParentHeader is the W3C Trace Context header carrying the trace
+const traceParentHeader = "traceparent"
+
+// WithTraceContext returns a child logger with the trace_id, span_id and
+// trace_flags fields attached to every line, the same field names used by the
+// otellog module, to correlate the lines with a distributed trace. The empty
+// values are not written.
+func (l *Logger) WithTraceContext(traceID, spanID, traceFlags string) *Logger {
+	fields := make([]Field, 0, 3)
+	for _, field := range []Field{
+		{Key: "trace_id", Value: traceID},
+		{Key: "span_id", Value: spanID},
+		{Key: "trace_flags", Value: traceFlags},
+	} {
+		if field.Value != "" {
+			fields = append(fields, field)
+		}
+	}
+	return l.withFields(fields...)
+}
+
+// WithTraceContextFromHeader returns a child logger with the trace context of
+// the traceparent header of h, formatted as version-traceid-spanid-flags, or
+// l itself when the header is missing or invalid
+func (l *Logger) WithTraceContextFromHeader(h http.Header) *Logger {
+	traceID, spanID, traceFlags, ok := parseTraceParent(h.Get(traceParentHeader))
+	if !ok {
+		return l
+	}
+	return l.WithTraceContext(traceID, spanID, traceFlags)
+}
+
+// parseTraceParent split the traceparent value, the IDs must be lower case
+// hex and not all zero. The later versions may append more parts which are
+// ignored, but the version ff is invalid.
+func parseTraceParent(value string) (string, string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", "", "", false
+	}
+	version, traceID, spanID, traceFlags := parts[0], parts[1], parts[2], parts[3]
+	if !isTraceHex(version, 2) || version == "ff" || version == "00" && len(parts) != 4 {
+		return "", "", "", false
+	}
+	if !isTraceHex(traceID, 32) || isZeroID(traceID) || !isTraceHex(spanID, 16) || isZeroID(spanID) {
+		return "", "", "", false
+	}
+	if !isTraceHex(traceFlags, 2) {
+		return "", "", "", false
+	}
+	return traceID, spanID, traceFlags, true
+}
+
+// isTraceHex check whether s is n lower case hex digits
+func isTraceHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZeroID check whether the ID is made of zeros only, which is invalid
+func isZeroID(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
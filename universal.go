@@ -0,0 +1,173 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// UniversalWriter adapt a Logger to the io.Writer, slog.Handler and the
+// Print methods of the standard log.Logger at once, so the same value can be
+// given to log.SetOutput, http.Server.ErrorLog, grpclog and slog.New
+type UniversalWriter struct {
+	l      *Logger
+	level  Level
+	fields []Field
+	group  string
+}
+
+// NewUniversalWriter returns a writer logging to l, the lines without level
+// prefix and the Print methods are logged at level
+func NewUniversalWriter(l *Logger, level Level) *UniversalWriter {
+	return &UniversalWriter{l: l, level: level}
+}
+
+// Write log p at the level of its prefix such as [ERROR], WARN: or INFO,
+// which is removed from the message, or at the default level without prefix
+func (w *UniversalWriter) Write(p []byte) (int, error) {
+	level, msg := w.level, p
+	if lv, rest, ok := cutLevel(p); ok {
+		level, msg = lv, rest
+	}
+	if err := w.output(1, level, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Print log the arguments at the default level in the manner of fmt.Print
+func (w *UniversalWriter) Print(v ...interface{}) {
+	w.output(1, w.level, []byte(fmt.Sprint(v...)))
+}
+
+// Printf log the arguments at the default level in the manner of fmt.Printf
+func (w *UniversalWriter) Printf(format string, v ...interface{}) {
+	w.output(1, w.level, []byte(fmt.Sprintf(format, v...)))
+}
+
+// Println log the arguments at the default level in the manner of
+// fmt.Println
+func (w *UniversalWriter) Println(v ...interface{}) {
+	w.output(1, w.level, []byte(fmt.Sprintln(v...)))
+}
+
+// Enabled check whether the records of the slog level are written
+func (w *UniversalWriter) Enabled(_ context.Context, level slog.Level) bool {
+	return w.l.mayEnable(slogLevel(level))
+}
+
+// Handle log the slog record with its attributes as fields
+func (w *UniversalWriter) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, w.group, attr)
+		return true
+	})
+	fields = append(w.fields[:len(w.fields):len(w.fields)], fields...)
+	return w.l.output(3, w.l.prefix(slogLevel(r.Level)), []byte(r.Message), fields, nil)
+}
+
+// WithAttrs returns a handler adding the attributes to every record
+func (w *UniversalWriter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *w
+	clone.fields = w.fields[:len(w.fields):len(w.fields)]
+	for _, attr := range attrs {
+		clone.fields = appendAttr(clone.fields, w.group, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler qualifying the keys of the next attributes
+// with the group name, such as req.method
+func (w *UniversalWriter) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return w
+	}
+	clone := *w
+	clone.group = w.group + name + "."
+	return &clone
+}
+
+// output log msg at level with the fields of the writer
+func (w *UniversalWriter) output(depth int, level Level, msg []byte) error {
+	return w.l.output(depth+1, w.l.prefix(level), msg, w.fields, nil)
+}
+
+// appendAttr append the attribute as field, the groups are flattened with
+// the dotted keys
+func appendAttr(fields []Field, group string, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			group += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			fields = appendAttr(fields, group, member)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: group + attr.Key, Value: attr.Value.Any()})
+}
+
+// slogLevel returns the level of the slog level, the levels below debug are
+// trace
+func slogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// levelNames map the upper case level prefixes to their level
+var levelNames = map[string]Level{
+	"TRACE":   LevelTrace,
+	"DEBUG":   LevelDebug,
+	"INFO":    LevelInfo,
+	"WARN":    LevelWarn,
+	"WARNING": LevelWarn,
+	"ERROR":   LevelError,
+	"FATAL":   LevelFatal,
+}
+
+// cutLevel returns the level of the prefix of p, such as [ERROR], warn: or
+// INFO followed by a space, and the rest of p after the prefix
+func cutLevel(p []byte) (Level, []byte, bool) {
+	rest := bytes.TrimLeft(p, " ")
+	bracketed := len(rest) > 0 && rest[0] == '['
+	if bracketed {
+		rest = rest[1:]
+	}
+	end := 0
+	for end < len(rest) && (rest[end] >= 'A' && rest[end] <= 'Z' || rest[end] >= 'a' && rest[end] <= 'z') {
+		end++
+	}
+	level, ok := levelNames[string(bytes.ToUpper(rest[:end]))]
+	if !ok || end == len(rest) {
+		return 0, p, false
+	}
+	// An unbracketed name followed by a space is a prefix only in upper case
+	// so the messages starting with a word such as Error are kept
+	switch sep := rest[end]; {
+	case bracketed && sep == ']', !bracketed && sep == ':':
+	case !bracketed && sep == ' ' && bytes.Equal(rest[:end], bytes.ToUpper(rest[:end])):
+	default:
+		return 0, p, false
+	}
+	return level, bytes.TrimLeft(rest[end+1:], " "), true
+}
@@ -0,0 +1,68 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+//
+// Test file
+
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	stdlog "log"
+	"log/slog"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUniversalWriter(t *testing.T) {
+	Convey("Given universal writer", t, func() {
+		var out testWriter
+		l := newLogger(Config{Out: &out}).WithoutCaller().WithDebug()
+		w := NewUniversalWriter(l, LevelInfo)
+
+		Convey("It should log the lines of the standard logger", func() {
+			stdlog.New(w, "", 0).Print("hello")
+			So(out.String(), ShouldEqual, "[][INFO]  hello\n")
+		})
+
+		Convey("It should map the level prefix of the written line", func() {
+			w.Write([]byte("[ERROR] failed\n"))
+			w.Write([]byte("warn: careful\n"))
+			w.Write([]byte("DEBUG details\n"))
+			So(out.String(), ShouldEqual, "[][ERROR] failed\n[][WARN]  careful\n[][DEBUG] details\n")
+		})
+
+		Convey("It should keep a leading word which is not a prefix", func() {
+			w.Write([]byte("Error rate is low\n"))
+			So(out.String(), ShouldEqual, "[][INFO]  Error rate is low\n")
+		})
+
+		Convey("It should log the Print methods at the default level", func() {
+			NewUniversalWriter(l, LevelWarn).Printf("retry %d", 2)
+			So(out.String(), ShouldEqual, "[][WARN]  retry 2\n")
+		})
+
+		Convey("It should handle the slog records with the attributes as fields", func() {
+			logger := slog.New(w).With("service", "api").WithGroup("req")
+			logger.Warn("slow request", "method", "GET", slog.Group("user", "id", 42))
+			So(out.String(), ShouldEqual, "[][WARN]  slow request service=api req.method=GET req.user.id=42\n")
+		})
+
+		Convey("It should return ErrClosed after Close", func() {
+			l.Close()
+			So(slog.New(w).With("service", "api").Handler().Handle(context.Background(), slog.Record{}), ShouldEqual, ErrClosed)
+			_, err := w.Write([]byte("hello\n"))
+			So(err, ShouldEqual, ErrClosed)
+			So(out.String(), ShouldEqual, "")
+		})
+
+		Convey("It should map the slog levels", func() {
+			l.WithLevel(LevelWarn)
+			So(w.Enabled(context.Background(), slog.LevelInfo), ShouldBeFalse)
+			So(w.Enabled(context.Background(), slog.LevelError), ShouldBeTrue)
+			So(slogLevel(slog.LevelDebug-4), ShouldEqual, LevelTrace)
+		})
+	})
+}
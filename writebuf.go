@@ -0,0 +1,256 @@
+// The colorful and simple logging library
+// Copyright (c) 2017 Fadhli Dzil Ikram
+
+package log
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// syncer is implemented by the outputs which can commit the written data to
+// stable storage, such as *os.File
+type syncer interface {
+	Sync() error
+}
+
+// bufferedWriter coalesce the lines written to the output into fewer writes
+type bufferedWriter struct {
+	mu   sync.Mutex
+	out  FdWriter
+	bw   *bufio.Writer
+	done chan struct{}
+	// level is the level of the buffered lines when the output need it, the
+	// buffer is flushed before a line of another level
+	level   Level
+	leveled bool
+}
+
+// WithWriteBuffer coalesce the lines into a buffer of size bytes written to
+// the current output when full and every flushInterval, reducing the number
+// of write calls under load. Fatal flush the buffer before exiting and Close
+// flush it before closing the output. Calling it again or replacing the
+// output flush and stop the previous buffer.
+func (l *Logger) WithWriteBuffer(size int, flushInterval time.Duration) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var w *bufferedWriter
+	l.update(func(c *Config) {
+		out := c.Out
+		if prev, ok := out.(*bufferedWriter); ok {
+			prev.stop()
+			out = prev.out
+		}
+		w = newBufferedWriter(out, size)
+		c.Out = w
+	})
+	if flushInterval > 0 {
+		go w.flusher(flushInterval)
+	}
+	return l
+}
+
+// newBufferedWriter returns a buffer of size bytes in front of out, the
+// lines keep their level when out need it
+func newBufferedWriter(out FdWriter, size int) *bufferedWriter {
+	w := &bufferedWriter{
+		out:  out,
+		done: make(chan struct{}),
+	}
+	if lw, ok := out.(levelWriter); ok {
+		w.bw = bufio.NewWriterSize(levelOutput{w: w, out: lw}, size)
+	} else {
+		w.bw = bufio.NewWriterSize(out, size)
+	}
+	return w
+}
+
+// levelOutput write the buffered lines to the output with their level
+type levelOutput struct {
+	w   *bufferedWriter
+	out levelWriter
+}
+
+// Write pass p to the output with the level of the buffered lines, or
+// without level for the lines written with Write
+func (o levelOutput) Write(p []byte) (int, error) {
+	if !o.w.leveled {
+		return o.w.out.Write(p)
+	}
+	return o.out.WriteLevel(o.w.level, p)
+}
+
+// Write append the line to the buffer, it is written to the output when the
+// buffer is full. Once the buffer is stopped the line is written right away.
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(false, 0, p)
+}
+
+// WriteLevel append the line to the buffer like Write, the level is passed
+// to the output when it need it such as TeeWriter
+func (w *bufferedWriter) WriteLevel(level Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(true, level, p)
+}
+
+// write buffer the line, flushing first the lines of another level, caller
+// must hold the lock
+func (w *bufferedWriter) write(leveled bool, level Level, p []byte) (int, error) {
+	lw, ok := w.out.(levelWriter)
+	if w.stopped() {
+		if ok && leveled {
+			return lw.WriteLevel(level, p)
+		}
+		return w.out.Write(p)
+	}
+	if ok && (leveled != w.leveled || level != w.level) {
+		if err := w.bw.Flush(); err != nil {
+			return 0, err
+		}
+		w.leveled, w.level = leveled, level
+	}
+	return w.bw.Write(p)
+}
+
+// stopped check whether the buffer is stopped or closed
+func (w *bufferedWriter) stopped() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop flush the buffer and end the flusher without closing the output, the
+// next lines are written right away
+func (w *bufferedWriter) stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped() {
+		return nil
+	}
+	close(w.done)
+	return w.bw.Flush()
+}
+
+// Fd returns the file descriptor of the output
+func (w *bufferedWriter) Fd() uintptr {
+	return w.out.Fd()
+}
+
+// Flush write the buffered lines to the output
+func (w *bufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bw.Flush()
+}
+
+// RotateNow write the buffered lines to the output and rotate it if it
+// support manual rotation
+func (w *bufferedWriter) RotateNow() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return rotateOutput(w.out)
+}
+
+// Sync flush the buffered lines and commit the output to stable storage if
+// it support syncing
+func (w *bufferedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if s, ok := w.out.(syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close flush the buffered lines and close the output if it implements
+// io.Closer
+func (w *bufferedWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped() {
+		return os.ErrClosed
+	}
+	close(w.done)
+	err := w.bw.Flush()
+	if closer, ok := w.out.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// flusher periodically flush the buffer until closed
+func (w *bufferedWriter) flusher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// flush write the pending data of the output and level writers supporting
+// flushing, such as the write buffer, the first error is returned
+func (l *Logger) flush() error {
+	c := l.config.Load()
+	var err error
+	for _, out := range append([]FdWriter{c.Out}, c.writers[:]...) {
+		if flusher, ok := out.(interface{ Flush() error }); ok {
+			if ferr := flusher.Flush(); err == nil {
+				err = ferr
+			}
+		}
+	}
+	return err
+}
+
+// stopBuffer flush and stop the write buffer of the output replaced by out,
+// the clones still using it write their lines right away
+func stopBuffer(c *Config, out FdWriter) {
+	if w, ok := c.Out.(*bufferedWriter); ok && FdWriter(w) != out {
+		w.stop()
+	}
+}
+
+// Sync flush the in-process buffers then commit the output to stable storage
+// when it support syncing such as *os.File, e.g. for audit logs which must
+// survive a crash. It is a no-op for the other outputs, and for the pipes and
+// terminals which can not be synced.
+func (l *Logger) Sync() error {
+	// Wait for the lines being written
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.flush(); err != nil {
+		return err
+	}
+	s, ok := l.config.Load().Out.(syncer)
+	if !ok {
+		return nil
+	}
+	if err := s.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}